@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// benchInterval is how often Benchmark mode reports throughput.
+const benchInterval = 2 * time.Second
+
+// benchSample is a point-in-time reading of the crawler's counters.
+type benchSample struct {
+	at         time.Time
+	pages      int64
+	bytes      int64
+	goroutines int
+	heap       uint64
+}
+
+func (c *Crawler) benchSample() benchSample {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return benchSample{
+		at:         time.Now(),
+		pages:      c.pagesFetched.Load(),
+		bytes:      c.bytesRead.Load(),
+		goroutines: runtime.NumGoroutine(),
+		heap:       mem.HeapAlloc,
+	}
+}
+
+// watchBenchmark prints the throughput of the last interval every
+// benchInterval, then a summary of the whole crawl once done is closed.
+func (c *Crawler) watchBenchmark(done <-chan struct{}, finished chan<- struct{}) {
+	defer close(finished)
+	ticker := time.NewTicker(benchInterval)
+	defer ticker.Stop()
+
+	start := c.benchSample()
+	prev, peak := start, start
+	for {
+		select {
+		case <-done:
+			end := c.benchSample()
+			peak.goroutines = max(peak.goroutines, end.goroutines)
+			peak.heap = max(peak.heap, end.heap)
+			c.printBenchSummary(start, end, peak)
+			return
+		case <-ticker.C:
+			cur := c.benchSample()
+			elapsed := cur.at.Sub(prev.at).Seconds()
+			c.printf("[%s] %.1f pages/s  %s/s  goroutines=%d  heap=%s\n", color.MagentaString("BENCH"),
+				float64(cur.pages-prev.pages)/elapsed, formatBytes(float64(cur.bytes-prev.bytes)/elapsed),
+				cur.goroutines, formatBytes(float64(cur.heap)))
+			peak.goroutines = max(peak.goroutines, cur.goroutines)
+			peak.heap = max(peak.heap, cur.heap)
+			prev = cur
+		}
+	}
+}
+
+func (c *Crawler) printBenchSummary(start, end, peak benchSample) {
+	elapsed := end.at.Sub(start.at)
+	secs := max(elapsed.Seconds(), 1e-9)
+	pages := end.pages - start.pages
+	bytes := end.bytes - start.bytes
+	c.printf("\n%s\n", color.MagentaString("=== Benchmark ==="))
+	c.printf("duration:   %s\n", elapsed.Round(time.Millisecond))
+	c.printf("pages:      %d (%.1f/s)\n", pages, float64(pages)/secs)
+	c.printf("bytes:      %s (%s/s)\n", formatBytes(float64(bytes)), formatBytes(float64(bytes)/secs))
+	c.printf("goroutines: %d peak\n", peak.goroutines)
+	c.printf("heap:       %s peak\n", formatBytes(float64(peak.heap)))
+	c.printf("workers:    %d crawl, %d validation\n", cap(c.semaphore), cap(c.validateSem))
+}
+
+func formatBytes(n float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
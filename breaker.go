@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// defaultBreakerCooldown is how long a tripped host is left alone when
+// BreakerCooldown is unset.
+const defaultBreakerCooldown = 30 * time.Second
+
+// maxRequeues bounds how many cooldowns a page refused by an open breaker
+// waits for, so a host that never recovers can't keep the crawl running.
+const maxRequeues = 3
+
+// errCircuitOpen is returned for requests to a host whose breaker is open.
+var errCircuitOpen = errors.New("circuit open, host is cooling down")
+
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // Requests flow, failures are counted
+	breakerOpen                         // Requests are refused until the cooldown ends
+	breakerHalfOpen                     // A single probe request tests recovery
+)
+
+// hostBreaker is the circuit breaker of a single host.
+type hostBreaker struct {
+	mu       sync.Mutex
+	state    breakerState
+	failures int // Consecutive, while closed
+	openedAt time.Time
+	probing  bool
+}
+
+// requestFailed classifies an outcome as a host failure: no response, rate
+// limiting or a server error.
+func requestFailed(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+func (c *Crawler) breakerFor(host string) *hostBreaker {
+	v, _ := c.breakers.LoadOrStore(host, &hostBreaker{})
+	return v.(*hostBreaker)
+}
+
+func (c *Crawler) breakerCooldown() time.Duration {
+	if c.Config.BreakerCooldown > 0 {
+		return c.Config.BreakerCooldown
+	}
+	return defaultBreakerCooldown
+}
+
+// breakerWait returns how long until host's breaker lets a request through
+// again. A half-open breaker is waiting on its probe, checked again shortly.
+func (c *Crawler) breakerWait(host string) time.Duration {
+	b := c.breakerFor(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wait := c.breakerCooldown() - time.Since(b.openedAt)
+	if b.state != breakerOpen || wait < 100*time.Millisecond {
+		wait = 100 * time.Millisecond
+	}
+	return wait
+}
+
+// requeue crawls req again once its host's breaker lets requests through.
+// Its URL is already in Visited, so nothing else would ever fetch it.
+func (c *Crawler) requeue(req *http.Request, depth int) {
+	u := req.URL.String()
+	v, _ := c.requeued.LoadOrStore(u, new(atomic.Int32))
+	if v.(*atomic.Int32).Add(1) > maxRequeues {
+		if c.Config.Verbose {
+			c.printf("[%s] %s: host still failing, page dropped\n", color.RedString("ERR"), u)
+		}
+		return
+	}
+	if req.Method == "GET" {
+		c.frontier.Store(u, depth)
+	}
+
+	// The page's held result waits for the retry, not for this attempt's end
+	held, isHeld := c.held.LoadAndDelete(u)
+
+	c.wg.Add(1)
+	c.levelStart(depth)
+	go func() {
+		defer c.wg.Done()
+		defer c.levelDone(depth)
+		if isHeld {
+			c.held.Store(u, held)
+		}
+		defer c.releaseResult(u)
+		select {
+		case <-time.After(c.breakerWait(req.URL.Host)):
+		case <-c.context().Done():
+			return
+		}
+		if !c.acquire(c.semaphore) {
+			return
+		}
+		defer func() { <-c.semaphore }()
+
+		retry := req.Clone(c.context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return
+			}
+			retry.Body = body
+		}
+		c.crawlRequest(retry, depth)
+		if c.context().Err() == nil {
+			c.frontier.Delete(u)
+		}
+	}()
+}
+
+// breakerAllow reports whether a request to host may be sent. Once the
+// cooldown of an open breaker ends, one probe request is let through.
+func (c *Crawler) breakerAllow(host string) bool {
+	if c.Config.BreakerThreshold <= 0 {
+		return true
+	}
+
+	b := c.breakerFor(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < c.breakerCooldown() {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// breakerRecord feeds the outcome of a request to host into its breaker.
+func (c *Crawler) breakerRecord(host string, failed bool) {
+	if c.Config.BreakerThreshold <= 0 {
+		return
+	}
+	b := c.breakerFor(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= c.Config.BreakerThreshold {
+			b.state = breakerOpen
+			b.openedAt = time.Now()
+			c.printf("[%s] %s: %d consecutive failures, pausing requests\n", color.YellowString("WRN"), host, b.failures)
+		}
+	case breakerHalfOpen:
+		b.probing = false
+		if failed {
+			b.state = breakerOpen
+			b.openedAt = time.Now()
+			return
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		if c.Config.Verbose {
+			c.printf("[%s] %s: recovered, resuming requests\n", color.GreenString("INF"), host)
+		}
+	}
+}
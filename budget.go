@@ -0,0 +1,28 @@
+package main
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// hostExpired reports whether host has used up its MaxRuntimePerHost budget,
+// counted from the first page fetched on it. The host is recorded in
+// TimeCapped the first time its budget runs out.
+func (c *Crawler) hostExpired(host string) bool {
+	if c.Config.MaxRuntimePerHost <= 0 {
+		return false
+	}
+	v, _ := c.hostDeadlines.LoadOrStore(host, time.Now().Add(c.Config.MaxRuntimePerHost))
+	if time.Now().Before(v.(time.Time)) {
+		return false
+	}
+
+	if _, loaded := c.timeCapped.LoadOrStore(host, true); !loaded {
+		c.printf("[%s] %s: time budget of %s exhausted\n", color.YellowString("WRN"), host, c.Config.MaxRuntimePerHost)
+		c.resultsMu.Lock()
+		c.TimeCapped = append(c.TimeCapped, host)
+		c.resultsMu.Unlock()
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// defaultValidationCacheTTL is how long a persisted validation is trusted.
+const defaultValidationCacheTTL = 24 * time.Hour
+
+type cachedValidation struct {
+	Status      int       `json:"status,omitempty"`
+	Valid       bool      `json:"valid"`
+	Loop        bool      `json:"loop,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Redirect    int       `json:"redirect,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+func (c *Crawler) validationCacheTTL() time.Duration {
+	if c.Config.ValidationCacheTTL > 0 {
+		return c.Config.ValidationCacheTTL
+	}
+	return defaultValidationCacheTTL
+}
+
+// loadValidationCache seeds validCache with the entries of
+// ValidationCachePath younger than the TTL. A missing file is not an error,
+// it is created by the first SaveValidationCache.
+func (c *Crawler) loadValidationCache() error {
+	data, err := os.ReadFile(c.Config.ValidationCachePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries map[string]cachedValidation
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("invalid validation cache %s: %w", c.Config.ValidationCachePath, err)
+	}
+
+	ttl := c.validationCacheTTL()
+	for u, e := range entries {
+		if time.Since(e.CheckedAt) > ttl {
+			continue
+		}
+		c.validCache.Store(u, validation{Valid: e.Valid, Status: e.Status, Loop: e.Loop, ContentType: e.ContentType, Title: e.Title, Redirect: e.Redirect, Location: e.Location, CheckedAt: e.CheckedAt})
+	}
+	return nil
+}
+
+// SaveValidationCache writes validCache to ValidationCachePath. Network
+// errors are left out so they are retried on the next run.
+func (c *Crawler) SaveValidationCache() error {
+	if c.Config.ValidationCachePath == "" {
+		return nil
+	}
+	entries := make(map[string]cachedValidation)
+	c.validCache.Range(func(k, v any) bool {
+		val := v.(validation)
+		if val.Status == 0 && !val.Loop {
+			return true
+		}
+		entries[k.(string)] = cachedValidation{
+			Status:      val.Status,
+			Valid:       val.Valid,
+			Loop:        val.Loop,
+			ContentType: val.ContentType,
+			Title:       val.Title,
+			Redirect:    val.Redirect,
+			Location:    val.Location,
+			CheckedAt:   val.CheckedAt,
+		}
+		return true
+	})
+
+	file, err := os.Create(c.Config.ValidationCachePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
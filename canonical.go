@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/url"
+
+	"github.com/fatih/color"
+)
+
+// heldResult is the result of a page waiting to be fetched, see holdResult.
+type heldResult struct {
+	li      linkInfo
+	foundOn string
+	depth   int
+}
+
+// holdResult records li once its page is fetched rather than now when
+// HonorCanonical is set, so a page declaring another canonical URL reaches
+// every output under that URL. The caller schedules the page. Its depth is
+// kept open until the result is released.
+func (c *Crawler) holdResult(li linkInfo, foundOn string, depth int) {
+	if !c.Config.HonorCanonical {
+		c.addResult(li, foundOn, depth)
+		return
+	}
+	c.levelStart(depth)
+	c.held.Store(li.url, heldResult{li: li, foundOn: foundOn, depth: depth})
+}
+
+// releaseResult records the result held for u, if any, unchanged.
+func (c *Crawler) releaseResult(u string) {
+	if v, ok := c.held.LoadAndDelete(u); ok {
+		h := v.(heldResult)
+		c.addResult(h.li, h.foundOn, h.depth)
+		c.levelDone(h.depth)
+	}
+}
+
+// dropResult discards the result held for u, if any.
+func (c *Crawler) dropResult(u string) {
+	if v, ok := c.held.LoadAndDelete(u); ok {
+		c.levelDone(v.(heldResult).depth)
+	}
+}
+
+// hasResult reports whether u is a result, recorded or held.
+func (c *Crawler) hasResult(u string) bool {
+	if _, ok := c.held.Load(u); ok {
+		return true
+	}
+	c.resultsMu.Lock()
+	defer c.resultsMu.Unlock()
+	_, ok := c.resultIndex[u]
+	return ok
+}
+
+// canonicalize collapses page onto the canonical URL it declares, when in
+// scope: the result held for page is recorded under the canonical URL, or
+// dropped when that URL is a result of its own. false tells the caller not
+// to recurse, the canonical page was crawled already.
+func (c *Crawler) canonicalize(page *url.URL, href string) bool {
+	target, err := page.Parse(href)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return true
+	}
+	// Syndicated copies point at another site, the page is still ours
+	if !c.inScope(target, page) {
+		return true
+	}
+	canon := normalizeURL(target)
+	original := page.String()
+	if canon == original {
+		return true
+	}
+	if c.Config.Verbose {
+		c.printf("[%s] %s: canonical is %s\n", color.YellowString("WRN"), original, canon)
+	}
+
+	_, seen := c.Visited.LoadOrStore(canon, true)
+	if _, crawled := c.crawled.Load(canon); crawled {
+		c.dropResult(original)
+		c.filterOut(original, "duplicate of canonical "+canon)
+		return false
+	}
+	if seen && c.hasResult(canon) {
+		c.dropResult(original)
+		c.filterOut(original, "duplicate of canonical "+canon)
+		return true
+	}
+
+	// The target and seed requests have no result of their own
+	v, ok := c.held.LoadAndDelete(original)
+	if !ok {
+		return true
+	}
+	h := v.(heldResult)
+	h.li.url = canon
+	h.li.original = original
+	c.addResult(h.li, h.foundOn, h.depth)
+	c.levelDone(h.depth)
+	c.filterOut(original, "reported as canonical "+canon)
+	return true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Checkpoint is a snapshot of a crawl in progress: the URLs already
+// visited, the pages scheduled but not crawled yet, and the results so far.
+type Checkpoint struct {
+	Target   string          `json:"target"`
+	SavedAt  time.Time       `json:"saved_at"`
+	Visited  []string        `json:"visited"`
+	Frontier []FrontierEntry `json:"frontier"`
+	Results  []Result        `json:"results"`
+}
+
+// FrontierEntry is a page waiting to be crawled at Depth.
+type FrontierEntry struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+}
+
+// autoSave writes a checkpoint every AutoSaveInterval until done is closed,
+// then a last one reflecting the finished crawl.
+func (c *Crawler) autoSave(done <-chan struct{}, finished chan<- struct{}) {
+	defer close(finished)
+	ticker := time.NewTicker(c.Config.AutoSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			c.saveCheckpoint()
+			return
+		case <-ticker.C:
+			c.saveCheckpoint()
+		}
+	}
+}
+
+func (c *Crawler) saveCheckpoint() {
+	if err := c.SaveCheckpoint(c.Config.StatePath); err != nil {
+		c.printf("[%s] checkpoint: %v\n", color.YellowString("WRN"), err)
+	}
+}
+
+// SaveCheckpoint writes the crawl state to path. The state is written to a
+// temporary file in the same directory then renamed over path, so a crash
+// mid-write leaves the previous checkpoint intact.
+func (c *Crawler) SaveCheckpoint(path string) error {
+	cp := Checkpoint{Target: c.Config.TargetURL, SavedAt: time.Now()}
+	c.Visited.Range(func(k, _ any) bool {
+		cp.Visited = append(cp.Visited, k.(string))
+		return true
+	})
+	sort.Strings(cp.Visited)
+	c.frontier.Range(func(k, v any) bool {
+		cp.Frontier = append(cp.Frontier, FrontierEntry{URL: k.(string), Depth: v.(int)})
+		return true
+	})
+	sort.Slice(cp.Frontier, func(i, j int) bool { return cp.Frontier[i].URL < cp.Frontier[j].URL })
+	c.resultsMu.Lock()
+	cp.Results = append([]Result(nil), c.Results...)
+	c.resultsMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	encoder := json.NewEncoder(tmp)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// CookieRule sends Cookies with the requests whose URL matches Pattern, a
+// regex; an empty pattern matches the URLs in scope of the target. When
+// several rules match, later ones override earlier cookies of the same name.
+type CookieRule struct {
+	Pattern string
+	Cookies []*http.Cookie
+}
+
+type cookieRule struct {
+	re      *regexp.Regexp // nil matches the URLs in scope
+	cookies []*http.Cookie
+}
+
+// compileCookieRules compiles the rule patterns, reporting the first
+// invalid one.
+func compileCookieRules(rules []CookieRule) ([]cookieRule, error) {
+	compiled := make([]cookieRule, 0, len(rules))
+	for _, r := range rules {
+		cr := cookieRule{cookies: r.Cookies}
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cookie pattern %q: %w", r.Pattern, err)
+			}
+			cr.re = re
+		}
+		compiled = append(compiled, cr)
+	}
+	return compiled, nil
+}
+
+// ParseCookieRule parses "[pattern::]name=value; name=value" as given to
+// --cookie.
+func ParseCookieRule(s string) (CookieRule, error) {
+	pattern, list, ok := strings.Cut(s, "::")
+	if !ok {
+		pattern, list = "", s
+	}
+	cookies, err := http.ParseCookie(strings.TrimSpace(list))
+	if err != nil {
+		return CookieRule{}, fmt.Errorf("invalid cookies %q: %w", list, err)
+	}
+	return CookieRule{Pattern: pattern, Cookies: cookies}, nil
+}
+
+// applyCookies adds the cookies of every rule matching the URL of req.
+func (c *Crawler) applyCookies(req *http.Request) {
+	if len(c.cookieRules) == 0 {
+		return
+	}
+	u := req.URL.String()
+	internal := c.isInternal(u)
+	var names []string
+	byName := make(map[string]*http.Cookie)
+	for _, r := range c.cookieRules {
+		if r.re == nil && !internal || r.re != nil && !r.re.MatchString(u) {
+			continue
+		}
+		for _, ck := range r.cookies {
+			if _, ok := byName[ck.Name]; !ok {
+				names = append(names, ck.Name)
+			}
+			byName[ck.Name] = ck
+		}
+	}
+	for _, name := range names {
+		req.AddCookie(byName[name])
+	}
+}
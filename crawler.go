@@ -2,98 +2,576 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"math"
+	"math/rand/v2"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/Azure/go-ntlmssp"
+	"github.com/andybalholm/cascadia"
 	"github.com/fatih/color"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultAccept is sent on page fetches so content-negotiating servers return
+// HTML rather than JSON or other representations.
+const defaultAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+// defaultUserAgent identifies the crawler when UserAgent is unset, since
+// some sites refuse Go's default User-Agent outright.
+var defaultUserAgent = "Yg-scovery/" + strings.TrimPrefix(Version, "v")
+
+// maxBodySize caps how much of a page is read, since chunked responses carry
+// no Content-Length to bound them up front.
+const maxBodySize = 10 << 20
+
 // Config holds configuration parameters for the crawler.
 type Config struct {
-	TargetURL    string
-	MaxDepth     int
-	OnlyInternal bool
-	OnlyExternal bool
-	OutputPath   string
-	Verbose      bool
-	ShowTree     bool
+	TargetURL           string // file:///path crawls a local dump offline
+	MaxDepth            int
+	OnlyInternal        bool
+	OnlyExternal        bool
+	OutputPath          string
+	Verbose             bool
+	ShowTree            bool
+	DirStats            bool
+	OutputStyle         string // "absolute" (default) or "relative"
+	Deterministic       bool
+	TokenRefresh        func() (string, error)    // Called on 401 to obtain a fresh bearer token
+	Cookies             []CookieRule              // Cookies sent per URL pattern, e.g. an admin session for /admin
+	RequestSigner       func(*http.Request) error // Called on every request just before it is sent, e.g. SigV4.Sign
+	RampUp              time.Duration
+	ProbeSensitiveFiles bool
+	SensitiveFiles      []string // Defaults to defaultSensitiveFiles
+	TracePath           string   // HAR file recording every request
+	TraceMaxRedirects   int      // Redirect hops recorded per request in the trace, all when 0
+	TraceSecrets        bool     // Keep credential and custom headers in the trace, redacted otherwise
+	CustomPatterns      []string // Extra extraction regexes, group 1 is the URL
+	MaxResponseTime     time.Duration
+	MaxPages            int    // Pages fetched (not merely validated) before the crawl stops, unlimited when 0
+	MinContentLength    int    // Don't recurse into pages with shorter bodies, likely placeholders or soft 404s
+	AuthScheme          string // "basic" or "ntlm", sent to in-scope hosts only; Negotiate challenges are answered with NTLM, not Kerberos
+	AuthUser            string // DOMAIN\user or user@domain for NTLM
+	AuthPassword        string
+	OnLevelComplete     func(depth int, results []Result) // Called once every page at a depth has been crawled
+	BlockPrivateIPs     bool
+	ExtractWithin       []string // CSS selectors scoping link extraction on HTML pages
+	ParseMode           string   // "dom" (default) parses HTML pages, "regex" scans them like other bodies
+	ExtractForms        bool
+	FetchTitles         bool                // Validate internal links with GET and record their <title>
+	FlagRedirects       bool                // Report links that only resolve through a redirect, with its Location
+	DefaultScheme       string              // Scheme for targets given without one, https when unset
+	MaxConcurrentReads  int                 // Bodies read and parsed at once, unbounded when 0
+	Canonical           bool                // Sorted JSON export without volatile fields
+	CrawlWorkers        int                 // Concurrent page fetches, NumCPU*4 (min 16) when 0
+	ValidationWorkers   int                 // Concurrent link validations, same default
+	IdleTimeout         time.Duration       // Stop when no new result appears for this long
+	Seeds               []Seed              // Extra depth-0 requests, e.g. POST search forms
+	UseSitemap          bool                // Crawl the URLs of the target's sitemap (or Sitemap) as depth-0 seeds
+	Templates           []string            // URL templates such as /users/{id}, validated as links of the target
+	TemplateValues      map[string][]string // Values substituted for each {placeholder}
+	RequireContent      string              // Only recurse into pages matching this regex or substring
+	HonorCanonical      bool                // Collapse pages onto their in-scope <link rel="canonical"> URL
+	ValidationCachePath string              // Persist link validations across runs
+	ValidationCacheTTL  time.Duration       // Age after which cached validations are re-checked, 24h when 0
+	CrawlWindow         TimeWindow          // Only send requests during this time of day
+	StreamPath          string              // JSON Lines file receiving each depth's results as it completes
+	StatePath           string              // Checkpoint file written every AutoSaveInterval
+	AutoSaveInterval    time.Duration       // How often StatePath is rewritten, never when 0
+	SessionIDPatterns   []string            // Session tokens stripped from URLs, see DefaultSessionIDPatterns
+	HashRouteMode       string              // "keep" (default), "collapse" or "routes", see HashRouteKeep
+	ScopeMode           string              // "host" (default) or "domain" (alias "subdomains"), which makes subdomains internal, see ScopeHost
+	MaxPathDepth        int                 // Ignore URLs with more path segments than this, unlimited when 0
+	IncludePatterns     []string            // Only keep URLs matching one of these regexes, all when empty
+	ExcludePatterns     []string            // Skip URLs matching any of these regexes, without probing them
+	Keyword             string              // Only extract links within KeywordWindow bytes of this word
+	KeywordWindow       int                 // Bytes kept around each Keyword occurrence, 512 when 0
+	MaxMatchesPerDoc    int                 // Matches kept per extractor or extraction regex on a page, unlimited when 0
+	MinURLLength        int                 // Drop extracted candidates and results shorter than this, whatever the extractor
+	QueryAsChild        bool                // Show query strings as child nodes of their path in the tree
+	SampleRate          float64             // Fraction (0-1) of internal pages recursed into, all when 0
+	Sinks               []Sink              // Extra outputs receiving each result as it is found
+	WebhookURL          string              // Endpoint each new result is POSTed to as JSON
+	Tracer              trace.Tracer        // OpenTelemetry tracer for crawl and request spans, disabled when nil
+	BreakerThreshold    int                 // Consecutive failures that pause a host, disabled when 0
+	BreakerCooldown     time.Duration       // Pause before a tripped host is probed again, 30s when 0
+	AdaptiveConcurrency bool                // Start with few requests in flight and adapt to the error rate
+	BandwidthLimit      int64               // Bytes per second read across all page bodies, unlimited when 0
+	UserAgent           string              // Yg-scovery/<version> when empty
+	ProxyURL            string              // http://, https:// or socks5:// proxy for every request
+	Headers             map[string]string   // Sent with in-scope requests; Accept replaces the page fetch default, Host sets the virtual host
+	RequestDelay        time.Duration       // Minimum interval between requests to the same host
+	RequestsPerSecond   float64             // Max requests per second to each host, unlimited when 0
+	ExtractOpenSearch   bool                // Fetch OpenSearch descriptions and record their search URL templates
+	ExtractIntegrity    bool                // Record scripts and stylesheets with their SRI hashes
+	MixedContent        bool                // Report http:// sub-resources loaded by HTTPS pages
+	MaxRuntimePerHost   time.Duration       // Stop crawling a host this long after its first page
+	ExternalTLDs        []string            // Only report external links under these TLDs or domains
+	Benchmark           bool                // Report pages/s, bytes/s, goroutines and memory while crawling
+	Estimate            bool                // Only fetch the target and project the crawl's breadth
+	SkipProtectedHosts  bool                // Stop crawling hosts that serve bot-protection challenges
+	RespectCrawlDelay   bool                // Space requests to each host by its robots.txt Crawl-delay
+	RespectRobots       bool                // Skip URLs robots.txt disallows, before probing them; implies RespectCrawlDelay
+	Render              bool                // Extract links from the DOM rendered by a headless browser, whose requests bypass BlockPrivateIPs, rate limits, robots.txt and the breaker
+	RenderEndpoint      string              // DevTools endpoint (http://host:9222), a local Chrome is started when empty
+	FollowPagination    bool                // Follow "next page" links at the same depth
+	PaginationPatterns  []string            // Link texts recognized as "next", see DefaultPaginationPatterns
+	PaginationSelectors []string            // CSS selectors of "next" links, tried when no rel="next" is found
+	Discrepancies       bool                // Export visited URLs left out of the results, with the reason
+	LinkRot             bool                // Export broken internal links with the pages linking to them
+	Sitemap             string              // Sitemap URL or file to measure coverage against
+	OutputDir           string              // Directory receiving one JSON file per result category
+	OutputBuffer        int                 // Bytes of console output buffered, unbuffered when 0
+	FlushInterval       time.Duration       // Max delay before buffered output is written, 1s when 0
+	Console             io.Writer           // Progress, warnings and reports, stdout when nil
 }
 
 // Crawler represents the main crawler instance with its configuration and state.
 type Crawler struct {
-	Config     Config
-	Client     *http.Client
-	FastClient *http.Client // Client rapide pour HEAD requests
-	Visited    sync.Map
-	Results    []string
-	resultsMu  sync.Mutex
-	wg         sync.WaitGroup
-	validCache sync.Map // Cache de validation des liens
-	semaphore  chan struct{}
-}
-
-// New creates and initializes a new Crawler instance with the given configuration.
-func New(cfg Config) *Crawler {
+	Config           Config
+	Client           *http.Client
+	FastClient       *http.Client // Client rapide pour HEAD requests
+	RunID            string
+	Metadata         Metadata
+	transport        *http.Transport
+	Visited          sync.Map
+	Results          []Result
+	Sensitive        []Result // Sensitive files found by probing
+	Forms            []Form
+	WebSockets       []Result
+	Emails           []Result         // Addresses of mailto: links
+	HashRoutes       []Result         // Client-side "#/..." routes, in HashRouteRoutes mode
+	OpenSearch       []SearchEndpoint // Search URL templates from OpenSearch descriptions
+	RobotsDisallowed []Result         // URLs skipped because of robots.txt
+	RedirectLoops    []Result
+	Broken           []BrokenLink    // Internal links failing validation, with LinkRot
+	Protected        []ProtectedHost // Hosts behind bot protection
+	TimeCapped       []string        // Hosts whose MaxRuntimePerHost ran out
+	MixedContent     []Result        // http:// sub-resources of HTTPS pages
+	Subresources     []Subresource   // Scripts and stylesheets with their integrity hashes
+	MergedTargets    []string        // Targets of the crawlers folded in by Merge
+	resultsMu        sync.Mutex
+	brokenIndex      map[string]int // URL -> index in Broken
+	resultIndex      map[string]int // URL -> index in Results
+	wg               sync.WaitGroup
+	validCache       sync.Map // Cache de validation des liens
+	hostBlocked      sync.Map // Host -> resolves to a private address
+	seenForms        sync.Map
+	seenLoops        sync.Map
+	protected        sync.Map
+	seenMixed        sync.Map
+	seenSRI          sync.Map
+	seenOpenSearch   sync.Map
+	filtered         sync.Map // URL -> why it was visited but not reported
+	crawled          sync.Map // Pages fetched by crawlRequest
+	frontier         sync.Map // URL -> depth of the pages scheduled but not crawled yet
+	held             sync.Map // URL -> heldResult, waiting for its page's canonical URL
+	renderer         *renderer
+	hostDeadlines    sync.Map // Host -> time.Time
+	timeCapped       sync.Map
+	breakers         sync.Map      // Host -> *hostBreaker
+	requeued         sync.Map      // URL -> *atomic.Int32, times refused by an open breaker
+	semaphore        chan struct{} // Page fetches
+	validateSem      chan struct{} // Link validation probes
+	readSem          chan struct{}
+	token            string
+	tokenMu          sync.RWMutex
+	startedAt        time.Time
+	inFlight         int
+	rampMu           sync.Mutex
+	trace            []harEntry
+	traceMu          sync.Mutex
+	patterns         []*regexp.Regexp
+	within           []cascadia.Sel
+	nextPatterns     []*regexp.Regexp
+	includes         []*regexp.Regexp
+	excludes         []*regexp.Regexp
+	nextSelectors    []cascadia.Sel
+	paginated        atomic.Int64
+	cookieRules      []cookieRule
+	required         *regexp.Regexp
+	sessionIDs       []*regexp.Regexp
+	sitemapURLs      []string
+	pacers           sync.Map // Host -> *hostPacer
+	limiters         sync.Map // Host -> *rate.Limiter
+	extractors       map[string]ExtractorFunc
+	extractorsMu     sync.RWMutex
+	bandwidth        *bandwidthLimiter
+	adaptive         *adaptiveLimiter
+	out              *bufio.Writer
+	outMu            sync.Mutex
+	stream           *bufio.Writer   // JSON Lines waves
+	spanCtx          context.Context // Carries the crawl span
+	ctx              context.Context // Cancels the crawl and its requests, set by StartContext
+	sinks            []Sink
+
+	halted     atomic.Bool
+	paused     atomic.Bool  // Outside CrawlWindow
+	lastResult atomic.Int64 // UnixNano of the latest result
+
+	pagesFetched  atomic.Int64 // Responses received by crawlRequest
+	pagesStarted  atomic.Int64 // Fetches reserved against MaxPages
+	requests      atomic.Int64 // Requests sent
+	requestErrors atomic.Int64 // Requests failed as seen by requestFailed
+	haltReason    atomic.Value // string
+	bytesRead     atomic.Int64 // Page body bytes read
+
+	levelPending map[int]int
+	nextLevel    int
+	levelMu      sync.Mutex
+}
+
+// Result is a discovered URL along with the page it was found on and when.
+type Result struct {
+	URL          string    `json:"url"`
+	Status       int       `json:"status,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	FoundOn      string    `json:"found_on,omitempty"`
+	DiscoveredAt time.Time `json:"discovered_at,omitzero"`
+	Depth        int       `json:"depth"`              // Crawl depth of the page it was found on, 0 for the target
+	Original     string    `json:"original,omitempty"` // URL linked to, when it declared another canonical URL
+	External     bool      `json:"external,omitempty"`
+	Pagination   bool      `json:"pagination,omitempty"` // Reached through a "next page" link
+	Title        string    `json:"title,omitempty"`
+	Redirect     int       `json:"redirect,omitempty"` // 3xx answered before Status, with FlagRedirects
+	Location     string    `json:"location,omitempty"`
+}
+
+// New creates and initializes a new Crawler instance with the given
+// configuration. It fails when one of the configured patterns or selectors
+// doesn't compile, or when options conflict.
+func New(cfg Config) (*Crawler, error) {
 	workers := runtime.NumCPU() * 4
 	if workers < 16 {
 		workers = 16
 	}
+	crawlWorkers, validationWorkers := workers, workers
+	if cfg.CrawlWorkers > 0 {
+		crawlWorkers = cfg.CrawlWorkers
+	}
+	if cfg.ValidationWorkers > 0 {
+		validationWorkers = cfg.ValidationWorkers
+	}
 
-	transport := &http.Transport{
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: false}, // Default to secure
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		MaxConnsPerHost:     20,
-		IdleConnTimeout:     30 * time.Second,
-		DisableKeepAlives:   false,
+	if cfg.AuthScheme != "" && cfg.TokenRefresh != nil {
+		return nil, errors.New("AuthScheme and TokenRefresh both set the Authorization header")
+	}
+	if cfg.Render && cfg.BlockPrivateIPs {
+		return nil, errors.New("Render can't honor BlockPrivateIPs, the browser makes its own requests")
+	}
+	switch cfg.ScopeMode {
+	case "", ScopeHost, ScopeDomain:
+	case ScopeSubdomains:
+		cfg.ScopeMode = ScopeDomain
+	default:
+		return nil, fmt.Errorf("invalid scope mode %q (host, domain or subdomains)", cfg.ScopeMode)
+	}
+	patterns, err := compilePatterns(cfg.CustomPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("custom patterns: %w", err)
+	}
+	sessionIDs, err := compilePatterns(cfg.SessionIDPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("session ID patterns: %w", err)
+	}
+	within, err := compileSelectors(cfg.ExtractWithin)
+	if err != nil {
+		return nil, fmt.Errorf("extraction scope: %w", err)
+	}
+	nextPatterns, err := compilePatterns(cfg.PaginationPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("pagination patterns: %w", err)
+	}
+	nextSelectors, err := compileSelectors(cfg.PaginationSelectors)
+	if err != nil {
+		return nil, fmt.Errorf("pagination selectors: %w", err)
+	}
+	includes, err := compilePatterns(cfg.IncludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("include patterns: %w", err)
+	}
+	excludes, err := compilePatterns(cfg.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("exclude patterns: %w", err)
+	}
+
+	cookieRules, err := compileCookieRules(cfg.Cookies)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Crawler{
-		Config: cfg,
-		Client: &http.Client{
-			Timeout:   60 * time.Second,
-			Transport: transport,
-		},
-		FastClient: &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		},
-		semaphore: make(chan struct{}, workers),
+	transport, err := newTransport(cfg, false)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+
+	c := &Crawler{
+		Config:        cfg,
+		patterns:      patterns,
+		sessionIDs:    sessionIDs,
+		within:        within,
+		nextPatterns:  nextPatterns,
+		includes:      includes,
+		excludes:      excludes,
+		nextSelectors: nextSelectors,
+		cookieRules:   cookieRules,
+		transport:     transport,
+		semaphore:     make(chan struct{}, crawlWorkers),
+		validateSem:   make(chan struct{}, validationWorkers),
+		levelPending:  make(map[int]int),
+	}
+	c.extractors = defaultExtractors(cfg.ParseMode, cfg.MaxMatchesPerDoc)
+	if cfg.AdaptiveConcurrency {
+		c.adaptive = newAdaptiveLimiter(cap(c.semaphore) + cap(c.validateSem))
+	}
+	if cfg.BandwidthLimit > 0 {
+		c.bandwidth = &bandwidthLimiter{rate: float64(cfg.BandwidthLimit)}
 	}
+	c.out = newOutput(c.console(), cfg.OutputBuffer)
+	if cfg.RequireContent != "" {
+		c.required, _ = regexp.Compile(cfg.RequireContent)
+	}
+	if cfg.MaxConcurrentReads > 0 {
+		c.readSem = make(chan struct{}, cfg.MaxConcurrentReads)
+	}
+	c.Client = &http.Client{
+		Timeout:       60 * time.Second,
+		Transport:     c.roundTripper(transport),
+		CheckRedirect: c.checkRedirect,
+	}
+	c.FastClient = &http.Client{
+		Timeout:       30 * time.Second,
+		Transport:     c.roundTripper(transport),
+		CheckRedirect: c.checkRedirect,
+	}
+	return c, nil
 }
 
-// Start initiates the crawling process starting from the target URL.
-func (c *Crawler) Start() error {
-	parsed, err := url.Parse(c.Config.TargetURL)
+// roundTripper wraps the base transport with the configured authentication scheme.
+func (c *Crawler) roundTripper(transport *http.Transport) http.RoundTripper {
+	if c.Config.AuthScheme == "ntlm" {
+		return ntlmTransport{crawler: c, next: ntlmssp.Negotiator{RoundTripper: transport}}
+	}
+	return transport
+}
+
+// ntlmTransport hands the credentials to the NTLM negotiator, which reads
+// them from the request's basic auth and replaces them with the handshake.
+// They are only added for in-scope hosts, hop by hop, so they never leave
+// the transport as a Basic header.
+type ntlmTransport struct {
+	crawler *Crawler
+	next    http.RoundTripper
+}
+
+func (t ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.crawler.isInternal(req.URL.String()) {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.crawler.Config.AuthUser, t.crawler.Config.AuthPassword)
+	return t.next.RoundTrip(req)
+}
+
+// Start initiates the crawling process starting from the target URL. The
+// summary is returned even when the crawl fails.
+func (c *Crawler) Start() (*Summary, error) {
+	return c.StartContext(context.Background())
+}
+
+// StartContext runs the crawl until it completes or ctx is cancelled. On
+// cancellation no new work is scheduled, requests in flight are aborted and
+// Start returns with the results collected so far, ready to be saved.
+func (c *Crawler) StartContext(ctx context.Context) (*Summary, error) {
+	c.ctx = ctx
+	stop := context.AfterFunc(ctx, func() { c.halt("interrupted") })
+	defer stop()
+	err := c.run()
+	return c.summarize(err), err
+}
+
+// context returns the context of the running crawl.
+func (c *Crawler) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// acquire takes a slot of sem, giving up when the crawl is cancelled so
+// goroutines queued for a slot exit instead of blocking shutdown.
+func (c *Crawler) acquire(sem chan struct{}) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-c.context().Done():
+		return false
+	}
+}
+
+func (c *Crawler) run() error {
+	c.startedAt = time.Now()
+	c.initMetadata()
+
+	if c.Config.ValidationCachePath != "" {
+		if err := c.loadValidationCache(); err != nil {
+			c.logf(color.YellowString, "[WRN] Ignoring validation cache: %v", err)
+		}
+	}
+
+	norm, err := c.prepareTarget()
 	if err != nil {
 		return err
 	}
-	norm := parsed.String()
+	c.Config.TargetURL = norm
+	c.Visited.Store(norm, true)
 
-	// Initial check for certificate errors
-	if err := c.checkConnection(norm); err != nil {
-		return err
+	if c.out != nil {
+		done := make(chan struct{})
+		defer c.Flush()
+		defer close(done)
+		go c.flushEvery(done)
 	}
 
-	c.Visited.Store(norm, true)
+	if c.Config.StreamPath != "" {
+		closeStream, err := c.openStream()
+		if err != nil {
+			return err
+		}
+		defer closeStream()
+	}
+
+	endSpan := c.startCrawlSpan(norm)
+	defer endSpan()
+	if c.Config.Render {
+		r, err := newRenderer(c.Config.RenderEndpoint)
+		if err != nil {
+			return fmt.Errorf("render: %w", err)
+		}
+		c.renderer = r
+		defer r.Close()
+	}
+	c.openSinks()
+	defer c.closeSinks()
+
+	if c.Config.Estimate {
+		return c.estimate(norm)
+	}
+
+	if c.Config.Sitemap != "" {
+		urls, err := c.loadSitemap(c.Config.Sitemap)
+		if err != nil {
+			c.logf(color.YellowString, "[WRN] Ignoring sitemap: %v", err)
+		}
+		c.sitemapURLs = urls
+	}
+
+	if c.Config.AutoSaveInterval > 0 && c.Config.StatePath != "" {
+		done, finished := make(chan struct{}), make(chan struct{})
+		go c.autoSave(done, finished)
+		defer func() {
+			close(done)
+			<-finished
+		}()
+	}
 
-	if err := c.crawl(norm, 0); err != nil {
+	if c.Config.Benchmark {
+		done, finished := make(chan struct{}), make(chan struct{})
+		go c.watchBenchmark(done, finished)
+		defer func() {
+			close(done)
+			<-finished
+		}()
+	}
+
+	c.lastResult.Store(time.Now().UnixNano())
+	if c.Config.IdleTimeout > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go c.watchIdle(done)
+	}
+
+	// Seeds are depth 0 as well, the level stays open until they are sent
+	c.levelStart(0)
+	err = c.crawl(norm, 0)
+	if err != nil {
+		c.levelDone(0)
 		return err
 	}
+	for _, seed := range c.Config.Seeds {
+		c.crawlSeed(seed)
+	}
+	c.crawlTemplates()
+	if c.Config.UseSitemap {
+		c.seedFromSitemap()
+	}
+	c.levelDone(0)
 	c.wg.Wait()
+
+	if c.Config.ProbeSensitiveFiles {
+		c.probeSensitive()
+	}
 	return nil
 }
 
+// prepareTarget normalizes the target URL and checks that it is reachable.
+// Schemeless targets such as "example.com" get DefaultScheme (https when
+// unset), falling back to plain http if the https attempt fails.
+func (c *Crawler) prepareTarget() (string, error) {
+	raw := c.Config.TargetURL
+	scheme := c.Config.DefaultScheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	schemeless := !hasScheme(raw)
+	if schemeless {
+		raw = scheme + "://" + raw
+	}
+
+	norm, err := c.checkTarget(raw)
+	if err != nil && schemeless && scheme == "https" && !strings.Contains(err.Error(), "aborted by user") {
+		if normHTTP, errHTTP := c.checkTarget("http://" + c.Config.TargetURL); errHTTP == nil {
+			return normHTTP, nil
+		}
+	}
+	return norm, err
+}
+
+func (c *Crawler) checkTarget(raw string) (string, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if c.isBlockedHost(parsed.Hostname()) {
+		return "", fmt.Errorf("target %s resolves to a private address", parsed.Hostname())
+	}
+	norm := normalizeURL(parsed)
+
+	// Initial check for certificate errors
+	if err := c.checkConnection(norm); err != nil {
+		return "", err
+	}
+	return norm, nil
+}
+
 func (c *Crawler) checkConnection(targetURL string) error {
 	// Try HEAD first
 	err := c.doRequest(targetURL, "HEAD")
@@ -116,18 +594,22 @@ func (c *Crawler) checkConnection(targetURL string) error {
 }
 
 func (c *Crawler) doRequest(url, method string) error {
-	req, err := http.NewRequest(method, url, nil)
+	req, err := c.newRequest(method, url)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.FastClient.Do(req)
+	resp, err := c.do(c.FastClient, req)
 	if err != nil {
 		errStr := strings.ToLower(err.Error())
+		// A plain HTTP server answering a TLS handshake is not a certificate issue
+		var recordErr tls.RecordHeaderError
+		if errors.As(err, &recordErr) {
+			return err
+		}
 		if strings.Contains(errStr, "x509") || strings.Contains(errStr, "certificate") || strings.Contains(errStr, "tls") || strings.Contains(errStr, "authority") {
 			// Check if we already enabled insecure mode to avoid double prompting
-			tr := c.FastClient.Transport.(*http.Transport)
-			if tr.TLSClientConfig.InsecureSkipVerify {
+			if c.transport.TLSClientConfig.InsecureSkipVerify {
 				return err // Already insecure, yet failing on SSL? Real error.
 			}
 
@@ -135,11 +617,11 @@ func (c *Crawler) doRequest(url, method string) error {
 				return promptErr
 			}
 			// Retry request with insecure client
-			reqRetry, errRetry := http.NewRequest(method, url, nil)
+			reqRetry, errRetry := c.newRequest(method, url)
 			if errRetry != nil {
 				return errRetry
 			}
-			resp, err = c.FastClient.Do(reqRetry)
+			resp, err = c.do(c.FastClient, reqRetry)
 			if err != nil {
 				return err
 			}
@@ -163,9 +645,130 @@ func (c *Crawler) doRequest(url, method string) error {
 	return nil
 }
 
+// newRequest builds an outgoing request carrying the User-Agent, the
+// configured headers and credentials, and the current bearer token, if any.
+func (c *Crawler) newRequest(method, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(c.context(), method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+	// Headers and credentials are only for the target, never for the external links we validate
+	internal := c.isInternal(rawURL)
+	if internal {
+		for name, value := range c.Config.Headers {
+			if strings.EqualFold(name, "Host") {
+				req.Host = value
+			} else {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+	if token := c.bearerToken(); token != "" && internal {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if c.Config.AuthScheme == "basic" && c.Config.AuthUser != "" && internal {
+		req.SetBasicAuth(c.Config.AuthUser, c.Config.AuthPassword)
+	}
+	c.applyCookies(req)
+	return req, nil
+}
+
+func (c *Crawler) userAgent() string {
+	if c.Config.UserAgent != "" {
+		return c.Config.UserAgent
+	}
+	return defaultUserAgent
+}
+
+// do sends req with client. On a 401 and when TokenRefresh is configured, the
+// token is refreshed and the request retried once with the new credentials.
+func (c *Crawler) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	c.waitWindow()
+	release := c.rampAcquire()
+	defer release()
+
+	c.waitCrawlDelay(req.URL)
+	c.waitHostRate(req.URL)
+	resp, err := c.send(client, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.Config.TokenRefresh == nil || !c.isInternal(req.URL.String()) {
+		return resp, err
+	}
+
+	used := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if err := c.refreshToken(used); err != nil {
+		if c.Config.Verbose {
+			c.printf("[%s] token refresh: %v\n", color.RedString("ERR"), err)
+		}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	retry.Header.Set("Authorization", "Bearer "+c.bearerToken())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return c.send(client, retry)
+}
+
+// send performs a single round of req, recording it in the trace if enabled.
+func (c *Crawler) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	if c.Config.RequestSigner != nil {
+		if err := c.Config.RequestSigner(req); err != nil {
+			return nil, err
+		}
+	}
+	// Through a proxy the dialer can't refuse private addresses, every request is checked here
+	if c.isBlockedHost(req.URL.Hostname()) {
+		return nil, fmt.Errorf("%w: %s", errBlockedHost, req.URL.Hostname())
+	}
+	if !c.breakerAllow(req.URL.Host) {
+		return nil, errCircuitOpen
+	}
+	started := time.Now()
+	endSpan := c.startRequestSpan(req)
+	release := c.adaptiveAcquire()
+	resp, err := client.Do(req)
+	release(resp, err)
+	c.requests.Add(1)
+	failed := requestFailed(resp, err)
+	if failed {
+		c.requestErrors.Add(1)
+	}
+	c.breakerRecord(req.URL.Host, failed)
+	endSpan(resp, err)
+	c.recordTrace(req, resp, started, err)
+	return resp, err
+}
+
+func (c *Crawler) bearerToken() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.token
+}
+
+// refreshToken asks TokenRefresh for a new token, unless another goroutine
+// already replaced the stale one while we were waiting for the lock.
+func (c *Crawler) refreshToken(stale string) error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token != stale {
+		return nil
+	}
+	token, err := c.Config.TokenRefresh()
+	if err != nil {
+		return err
+	}
+	c.token = token
+	return nil
+}
+
 func (c *Crawler) promptInsecure() error {
-	fmt.Printf("%s The target has an invalid/self-signed certificate.\n", color.YellowString("[!]"))
-	fmt.Print("Do you want to proceed anyway? [Y/n]: ")
+	fmt.Fprintf(c.console(), "%s The target has an invalid/self-signed certificate.\n", color.YellowString("[!]"))
+	fmt.Fprint(c.console(), "Do you want to proceed anyway? [Y/n]: ")
 
 	reader := bufio.NewReader(os.Stdin)
 	response, _ := reader.ReadString('\n')
@@ -180,47 +783,106 @@ func (c *Crawler) promptInsecure() error {
 }
 
 func (c *Crawler) enableInsecure() {
-	transport := &http.Transport{
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		MaxConnsPerHost:     20,
-		IdleConnTimeout:     30 * time.Second,
-		DisableKeepAlives:   false,
-	}
-	c.Client.Transport = transport
-	c.FastClient.Transport = transport
-	color.Yellow("[WRN] SSL verification disabled")
+	// The proxy was validated by New
+	transport, _ := newTransport(c.Config, true)
+	c.transport = transport
+	c.Client.Transport = c.roundTripper(transport)
+	c.FastClient.Transport = c.roundTripper(transport)
+	c.logf(color.YellowString, "[WRN] SSL verification disabled")
+}
+
+// schedule crawls u at depth in the background once a fetch slot is free.
+// Until its crawl completes, u is part of the frontier saved in checkpoints.
+func (c *Crawler) schedule(u string, depth int) {
+	c.wg.Add(1)
+	c.levelStart(depth)
+	c.frontier.Store(u, depth)
+	go func() {
+		defer c.wg.Done()
+		defer c.levelDone(depth)
+		// Whatever happens to the page, a result held for it is recorded
+		defer c.releaseResult(u)
+		if !c.acquire(c.semaphore) {
+			return
+		}
+		defer func() { <-c.semaphore }()
+		c.crawl(u, depth)
+		// Pages interrupted by cancellation stay in the frontier
+		if c.context().Err() == nil {
+			c.frontier.Delete(u)
+		}
+	}()
 }
 
 func (c *Crawler) crawl(rawURL string, depth int) error {
-	if depth >= c.Config.MaxDepth {
+	if depth >= c.Config.MaxDepth || c.stopped() {
 		return nil
 	}
-	parsed, err := url.Parse(rawURL)
+	req, err := c.newRequest("GET", rawURL)
 	if err != nil {
 		return err
 	}
+	// Links are checked before validation, this catches the target
+	if depth == 0 && !c.robotsAllowed(req.URL) {
+		c.addRobotsDisallowed(rawURL, "")
+		return nil
+	}
+	return c.crawlRequest(req, depth)
+}
 
-	resp, err := c.Client.Get(rawURL)
+// crawlRequest fetches a page with req, then validates, records and
+// recurses into the links it contains.
+func (c *Crawler) crawlRequest(req *http.Request, depth int) error {
+	parsed := req.URL
+	rawURL := parsed.String()
+	if c.isProtected(parsed.Host) || c.hostExpired(parsed.Host) {
+		return nil
+	}
+	// Fetches are reserved one by one, so concurrent workers can't overshoot
+	if limit := int64(c.Config.MaxPages); limit > 0 && c.pagesStarted.Add(1) > limit {
+		c.halt(fmt.Sprintf("page limit of %d reached", limit))
+		return nil
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", defaultAccept)
+	}
+
+	started := time.Now()
+	resp, err := c.do(c.Client, req)
+	if errors.Is(err, errCircuitOpen) {
+		// Nothing was fetched, the page is retried after the cooldown
+		c.pagesStarted.Add(-1)
+		c.requeue(req, depth)
+		return nil
+	}
 	if err != nil {
 		if c.Config.Verbose {
-			fmt.Printf("[%s] %s: %v\n", color.RedString("ERR"), rawURL, err)
+			c.printf("[%s] %s: %v\n", color.RedString("ERR"), rawURL, err)
 		}
 		return nil
 	}
 	defer resp.Body.Close()
+	c.crawled.Store(rawURL, true)
+	c.pagesFetched.Add(1)
 
 	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, wafProbeSize))
+		if vendor := detectWAF(resp, body); vendor != "" {
+			c.addProtectedHost(parsed.Host, vendor, rawURL)
+		}
 		return nil
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	// Server-sent events never end, reading them would block until timeout
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return nil
+	}
+
+	links, next, err := c.readLinks(resp, parsed, started)
 	if err != nil {
 		return err
 	}
-
-	links := Extract(string(body))
+	c.followPagination(next, parsed, depth)
 	validLinks := c.validateLinksParallel(links, parsed)
 
 	for _, linkInfo := range validLinks {
@@ -232,31 +894,196 @@ func (c *Crawler) crawl(rawURL string, depth int) error {
 		}
 
 		if isExternal {
-			if !c.Config.OnlyInternal {
-				fmt.Printf("[%s] %s\n", color.CyanString("EXT"), abs)
-				c.addResult(abs)
-			}
+			c.addResult(linkInfo, rawURL, depth)
 		} else {
-			if !c.Config.OnlyExternal {
-				fmt.Printf("[%s] %s\n", color.GreenString("INT"), abs)
-				c.addResult(abs)
+			recurse := !c.stopped() && !c.hostExpired(parsed.Host) && c.sampled(abs) && c.urlAllowed(abs)
+			switch {
+			case c.Config.OnlyExternal:
+				c.filterOut(abs, "internal link excluded by OnlyExternal")
+			case recurse:
+				c.holdResult(linkInfo, rawURL, depth)
+			default:
+				c.addResult(linkInfo, rawURL, depth)
+			}
+			if recurse {
+				c.schedule(abs, depth+1)
 			}
-
-			c.wg.Add(1)
-			go func(url string, d int) {
-				defer c.wg.Done()
-				c.semaphore <- struct{}{}
-				defer func() { <-c.semaphore }()
-				c.crawl(url, d+1)
-			}(abs, depth)
 		}
 	}
 	return nil
 }
 
+// readLinks reads a page body and extracts its links. Reading and parsing
+// are bounded by MaxConcurrentReads, independently of request concurrency,
+// so large bodies don't pile up in memory.
+func (c *Crawler) readLinks(resp *http.Response, page *url.URL, started time.Time) (links, next []string, err error) {
+	queued := time.Now()
+	if c.readSem != nil {
+		if !c.acquire(c.readSem) {
+			return nil, nil, c.context().Err()
+		}
+		defer func() { <-c.readSem }()
+	}
+	waited := time.Since(queued)
+
+	reader, err := decodeBody(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := io.ReadAll(io.LimitReader(c.throttle(reader), maxBodySize))
+	c.bytesRead.Add(int64(len(body)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Slow pages are kept as results but not descended into
+	if c.Config.MaxResponseTime > 0 {
+		if elapsed := time.Since(started) - waited; elapsed > c.Config.MaxResponseTime {
+			if c.Config.Verbose {
+				c.printf("[%s] %s: slow response (%s), not recursing\n", color.YellowString("WRN"), page, elapsed.Round(time.Millisecond))
+			}
+			return nil, nil, nil
+		}
+	}
+	// Short pages are kept as results but not descended into
+	if c.Config.MinContentLength > 0 && len(body) < c.Config.MinContentLength {
+		if c.Config.Verbose {
+			c.printf("[%s] %s: %d bytes body, not recursing\n", color.YellowString("WRN"), page, len(body))
+		}
+		return nil, nil, nil
+	}
+
+	if vendor := detectWAF(resp, body); vendor != "" {
+		c.addProtectedHost(page.Host, vendor, page.String())
+		return nil, nil, nil
+	}
+
+	content := string(body)
+	isHTML := strings.Contains(resp.Header.Get("Content-Type"), "html")
+	if c.renderer != nil && isHTML {
+		if rendered, err := c.renderer.render(page.String()); err != nil {
+			if c.Config.Verbose {
+				c.printf("[%s] %s: render: %v\n", color.RedString("ERR"), page, err)
+			}
+		} else {
+			content = rendered
+		}
+	}
+	if !c.matchesRequired(content) {
+		if c.Config.Verbose {
+			c.printf("[%s] %s: required content not found, not recursing\n", color.YellowString("WRN"), page)
+		}
+		return nil, nil, nil
+	}
+
+	if c.Config.FollowPagination {
+		next = linkHeaderNext(resp.Header)
+		if len(next) == 0 && isHTML {
+			next = NextLinks(content, c.nextPatterns, c.nextSelectors)
+		}
+	}
+	if c.Config.HonorCanonical && isHTML {
+		if href := CanonicalURL(content); href != "" && !c.canonicalize(page, href) {
+			return nil, nil, nil
+		}
+	}
+	// Description links sit in the head, outside any ExtractWithin scope
+	if c.Config.ExtractOpenSearch && isHTML {
+		c.addOpenSearch(OpenSearchLinks(content), page)
+	}
+	if len(c.within) > 0 && isHTML {
+		content = scopeContent(content, c.within)
+	}
+	if c.Config.Keyword != "" {
+		content = keywordWindows(content, c.Config.Keyword, c.Config.KeywordWindow)
+	}
+
+	if c.Config.ExtractIntegrity && isHTML {
+		c.addSubresources(ExtractSubresources(content), page)
+	}
+
+	if c.Config.MixedContent && isHTML && page.Scheme == "https" {
+		c.addMixedContent(InsecureSubresources(content), page.String())
+	}
+
+	if c.Config.ExtractForms && isHTML {
+		c.addForms(ExtractForms(content), page)
+	}
+
+	links = c.extract(resp.Header.Get("Content-Type"), content, page)
+	return links, next, nil
+}
+
+// decodeBody transparently decompresses gzip files (archived dumps,
+// sitemap.xml.gz...) so their content reaches the extractor. They are told
+// apart by their magic bytes, not their name, so a .gz URL serving plain
+// content is read as is. Responses using Content-Encoding are already
+// decoded by the transport.
+func decodeBody(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// isConnReset reports whether err is the server closing or resetting the
+// connection instead of answering.
+func isConnReset(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// matchesRequired reports whether content satisfies RequireContent, used as
+// a regex when it compiles and as a plain substring otherwise.
+func (c *Crawler) matchesRequired(content string) bool {
+	if c.Config.RequireContent == "" {
+		return true
+	}
+	if c.required != nil {
+		return c.required.MatchString(content)
+	}
+	return strings.Contains(content, c.Config.RequireContent)
+}
+
+// sampled reports whether u is among the SampleRate fraction of pages that
+// are recursed into. Deterministic runs pick pages by a hash of the URL so
+// repeated crawls sample the same ones.
+func (c *Crawler) sampled(u string) bool {
+	rate := c.Config.SampleRate
+	if rate <= 0 || rate >= 1 {
+		return true
+	}
+	if c.Config.Deterministic {
+		h := fnv.New64a()
+		h.Write([]byte(u))
+		return float64(h.Sum64())/math.MaxUint64 < rate
+	}
+	return rand.Float64() < rate
+}
+
 type linkInfo struct {
-	url        string
-	isExternal bool
+	url         string
+	isExternal  bool
+	status      int
+	pagination  bool
+	contentType string
+	title       string
+	redirect    int
+	location    string
+	original    string // URL linked to, when the page declared another canonical URL
+}
+
+// link returns the linkInfo of a link to u validated as v.
+func (v validation) link(u string, isExternal bool) linkInfo {
+	return linkInfo{
+		url:         u,
+		isExternal:  isExternal,
+		status:      v.Status,
+		contentType: v.ContentType,
+		title:       v.Title,
+		redirect:    v.Redirect,
+		location:    v.Location,
+	}
 }
 
 func (c *Crawler) validateLinksParallel(links []string, baseURL *url.URL) []linkInfo {
@@ -267,24 +1094,71 @@ func (c *Crawler) validateLinksParallel(links []string, baseURL *url.URL) []link
 		wg.Add(1)
 		go func(l string) {
 			defer wg.Done()
-			c.semaphore <- struct{}{}
-			defer func() { <-c.semaphore }()
+			if !c.acquire(c.validateSem) {
+				return
+			}
+			defer func() { <-c.validateSem }()
+			if c.stopped() {
+				return
+			}
 
 			res, err := baseURL.Parse(l)
 			if err != nil {
 				return
 			}
-			abs := res.String()
-			isExternal := res.Host != baseURL.Host
+			res = c.stripSessionIDs(res)
+			res = c.applyHashRouteMode(res, baseURL.String())
+			abs := normalizeURL(res)
+			isExternal := !c.inScope(res, baseURL)
 
 			if c.Config.OnlyInternal && isExternal {
+				c.skipLink(abs, "external link excluded by OnlyInternal")
+				return
+			}
+			if isExternal && !c.allowedTLD(res.Hostname()) {
+				c.skipLink(abs, "external TLD not in ExternalTLDs")
+				return
+			}
+			if c.Config.MaxPathDepth > 0 && pathDepth(res) > c.Config.MaxPathDepth {
+				c.skipLink(abs, fmt.Sprintf("deeper than MaxPathDepth %d", c.Config.MaxPathDepth))
+				return
+			}
+			if !c.urlAllowed(abs) {
+				c.skipLink(abs, "excluded by include/exclude patterns")
+				return
+			}
+			if res.Scheme == "mailto" {
+				c.addEmail(res, baseURL.String())
 				return
 			}
-			if c.validateLink(abs) {
-				results <- linkInfo{
-					url:        abs,
-					isExternal: isExternal,
+			// WebSocket endpoints can't be probed with HEAD, they are only recorded
+			if res.Scheme == "ws" || res.Scheme == "wss" {
+				c.addWebSocket(abs, baseURL.String())
+				return
+			}
+			if c.isBlockedHost(res.Hostname()) {
+				if c.Config.Verbose {
+					c.printf("[%s] %s: private address blocked\n", color.RedString("ERR"), abs)
+				}
+				c.skipLink(abs, errBlockedHost.Error())
+				return
+			}
+			if !c.robotsAllowed(res) {
+				if _, loaded := c.Visited.LoadOrStore(abs, true); !loaded {
+					c.addRobotsDisallowed(abs, baseURL.String())
 				}
+				return
+			}
+			v := c.validateLink(abs)
+			if v.Loop {
+				c.addRedirectLoop(abs, baseURL.String())
+			}
+			// Links refused by the circuit breaker aren't cached nor known broken
+			if _, checked := c.validCache.Load(abs); checked && !v.Valid && !isExternal {
+				c.addBrokenLink(abs, v.Status, baseURL.String())
+			}
+			if v.Valid {
+				results <- v.link(abs, isExternal)
 			}
 		}(link)
 	}
@@ -298,39 +1172,180 @@ func (c *Crawler) validateLinksParallel(links []string, baseURL *url.URL) []link
 	for li := range results {
 		validated = append(validated, li)
 	}
+	if c.Config.Deterministic {
+		sort.Slice(validated, func(i, j int) bool {
+			return validated[i].url < validated[j].url
+		})
+	}
 	return validated
 }
 
-func (c *Crawler) validateLink(u string) bool {
+// validation is the cached outcome of probing a link.
+type validation struct {
+	Valid       bool
+	Status      int
+	Loop        bool   // Redirects came back to an earlier URL
+	ContentType string // Media type, without parameters
+	Title       string // <title> of internal pages, when FetchTitles is set
+	Redirect    int    // Status of the first redirect, when FlagRedirects is set
+	Location    string // Where that redirect pointed
+	CheckedAt   time.Time
+}
+
+func (c *Crawler) validateLink(u string) validation {
 	if cached, ok := c.validCache.Load(u); ok {
-		return cached.(bool)
+		return cached.(validation)
 	}
 
-	req, err := http.NewRequest("HEAD", u, nil)
+	method := "HEAD"
+	if c.Config.FetchTitles && c.isInternal(u) {
+		method = "GET"
+	}
+	req, err := c.newRequest(method, u)
 	if err != nil {
-		c.validCache.Store(u, false)
-		return false
+		v := validation{CheckedAt: time.Now()}
+		c.validCache.Store(u, v)
+		return v
 	}
 
-	resp, err := c.FastClient.Do(req)
+	resp, err := c.do(c.FastClient, req)
+	if err != nil && isConnReset(err) {
+		// Some servers drop the connection on HEAD but answer GET
+		if get, gerr := c.newRequest("GET", u); gerr == nil {
+			resp, err = c.do(c.FastClient, get)
+		}
+	}
 	if err != nil {
 		if c.Config.Verbose {
-			fmt.Printf("[%s] %s: %v\n", color.RedString("ERR"), u, err)
+			c.printf("[%s] %s: %v\n", color.RedString("ERR"), u, err)
+		}
+		v := validation{Loop: errors.Is(err, errRedirectLoop), CheckedAt: time.Now()}
+		// Refused by the breaker or cancelled, the link is still unknown
+		if !errors.Is(err, errCircuitOpen) && c.context().Err() == nil {
+			c.validCache.Store(u, v)
+		}
+		return v
+	}
+	if req.Method == "HEAD" && headRejected(resp.StatusCode) {
+		if retry, err := c.rangedGet(u); err == nil {
+			resp.Body.Close()
+			resp = retry
 		}
-		c.validCache.Store(u, false)
-		return false
 	}
 	defer resp.Body.Close()
 
-	valid := resp.StatusCode >= 200 && resp.StatusCode < 400
-	c.validCache.Store(u, valid)
-	return valid
+	if vendor := detectWAF(resp, nil); vendor != "" {
+		c.addProtectedHost(req.URL.Host, vendor, u)
+	}
+
+	v := validation{
+		Valid:       resp.StatusCode >= 200 && resp.StatusCode < 400,
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		CheckedAt:   time.Now(),
+	}
+	if mediaType, _, err := mime.ParseMediaType(v.ContentType); err == nil {
+		v.ContentType = mediaType
+	}
+	if c.Config.FlagRedirects {
+		v.Redirect, v.Location = firstRedirect(resp)
+	}
+	if req.Method == "GET" && v.Valid && strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		if reader, err := decodeBody(resp.Body); err == nil {
+			head, _ := io.ReadAll(io.LimitReader(reader, titleProbeSize))
+			v.Title = PageTitle(string(head))
+		}
+	}
+	c.validCache.Store(u, v)
+	return v
+}
+
+// headRejected reports whether a HEAD answer may only mean the server
+// doesn't support HEAD, which CDNs and app servers often signal with 403,
+// 405 or 501 for resources GET serves fine.
+func headRejected(status int) bool {
+	return status == http.StatusForbidden || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented
+}
+
+// rangedGet re-checks u with a GET for its first byte. It completes the HEAD
+// validation already paced by do, so it is sent without waiting on the rate
+// limits again. A 206 is reported as 200 and a 416, the range not fitting an
+// empty resource, is retried without Range.
+func (c *Crawler) rangedGet(u string) (*http.Response, error) {
+	req, err := c.newRequest("GET", u)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := c.send(c.FastClient, req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		resp.StatusCode = http.StatusOK
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		req.Header.Del("Range")
+		return c.send(c.FastClient, req)
+	}
+	return resp, nil
+}
+
+// formatResult renders a result according to OutputStyle. In relative mode,
+// URLs on the target's host lose their scheme and host, other internal ones
+// only their scheme; external URLs stay absolute.
+func (c *Crawler) formatResult(raw string) string {
+	if c.Config.OutputStyle != "relative" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if !c.inTargets(u) {
+		return raw
+	}
+	u.Scheme = ""
+	u.User = nil
+	if hostKey(u) == hostKey(c.targetURL()) {
+		u.Host = ""
+	}
+	rel := u.String()
+	if !strings.HasPrefix(rel, "/") {
+		rel = "/" + rel
+	}
+	return rel
 }
 
-func (c *Crawler) addResult(url string) {
+func (c *Crawler) addResult(li linkInfo, foundOn string, depth int) {
+	// Seeds, sitemap and template URLs don't go through extract
+	if len(li.url) < c.Config.MinURLLength {
+		return
+	}
+	r := Result{
+		URL:          li.url,
+		Status:       li.status,
+		ContentType:  li.contentType,
+		FoundOn:      foundOn,
+		DiscoveredAt: time.Now(),
+		Depth:        depth,
+		External:     li.isExternal,
+		Pagination:   li.pagination,
+		Title:        li.title,
+		Redirect:     li.redirect,
+		Location:     li.location,
+		Original:     li.original,
+	}
 	c.resultsMu.Lock()
-	c.Results = append(c.Results, url)
+	if c.resultIndex == nil {
+		c.resultIndex = make(map[string]int)
+	}
+	c.resultIndex[r.URL] = len(c.Results)
+	c.Results = append(c.Results, r)
 	c.resultsMu.Unlock()
+	c.lastResult.Store(time.Now().UnixNano())
+	c.emit(r)
 }
 
 // SaveJSON exports the crawling results (and tree if enabled) to a JSON file.
@@ -339,10 +1354,32 @@ func (c *Crawler) SaveJSON() error {
 		return nil
 	}
 	type Export struct {
-		Target  string    `json:"target"`
-		Results []string  `json:"results"`
-		Tree    *treeNode `json:"tree,omitempty"`
-		Count   int       `json:"count"`
+		Metadata         *Metadata           `json:"metadata,omitempty"`
+		Target           string              `json:"target"`
+		MergedTargets    []string            `json:"merged_targets,omitempty"`
+		Results          []string            `json:"results"`
+		Details          []Result            `json:"details"`
+		Tree             *treeNode           `json:"tree,omitempty"`
+		Dirs             []DirStats          `json:"directories,omitempty"`
+		Sensitive        []Result            `json:"sensitive,omitempty"`
+		ExternalDomains  []string            `json:"external_domains,omitempty"`
+		Forms            []Form              `json:"forms,omitempty"`
+		WebSockets       []Result            `json:"websockets,omitempty"`
+		Emails           []Result            `json:"emails,omitempty"`
+		HashRoutes       []Result            `json:"hash_routes,omitempty"`
+		OpenSearch       []SearchEndpoint    `json:"opensearch,omitempty"`
+		RobotsDisallowed []Result            `json:"robots_disallowed,omitempty"`
+		RedirectLoops    []Result            `json:"redirect_loops,omitempty"`
+		BrokenLinks      []BrokenLink        `json:"broken_links,omitempty"`
+		Parameters       []string            `json:"parameters,omitempty"`
+		ParamEndpoints   map[string][]string `json:"parameter_endpoints,omitempty"`
+		SitemapCoverage  *SitemapCoverage    `json:"sitemap_coverage,omitempty"`
+		ProtectedHosts   []ProtectedHost     `json:"protected_hosts,omitempty"`
+		TimeCapped       []string            `json:"time_capped_hosts,omitempty"`
+		MixedContent     []Result            `json:"mixed_content,omitempty"`
+		Subresources     []Subresource       `json:"subresources,omitempty"`
+		Discrepancies    []Discrepancy       `json:"discrepancies,omitempty"`
+		Count            int                 `json:"count"`
 	}
 
 	var tree *treeNode
@@ -350,11 +1387,64 @@ func (c *Crawler) SaveJSON() error {
 		tree = c.buildTree()
 	}
 
+	results := make([]string, len(c.Results))
+	details := make([]Result, len(c.Results))
+	for i, r := range c.Results {
+		results[i] = c.formatResult(r.URL)
+		details[i] = r
+		details[i].URL = results[i]
+		details[i].FoundOn = c.formatResult(r.FoundOn)
+	}
+
+	var dirs []DirStats
+	if c.Config.DirStats {
+		dirs = c.directoryStats()
+	}
+
+	params, paramEndpoints := c.Parameters()
+
 	data := Export{
-		Target:  c.Config.TargetURL,
-		Results: c.Results,
-		Tree:    tree,
-		Count:   len(c.Results),
+		Metadata:         &c.Metadata,
+		Target:           c.Config.TargetURL,
+		MergedTargets:    c.MergedTargets,
+		Results:          results,
+		Details:          details,
+		Tree:             tree,
+		Dirs:             dirs,
+		Sensitive:        c.Sensitive,
+		ExternalDomains:  c.ExternalDomains(),
+		Forms:            c.Forms,
+		WebSockets:       c.WebSockets,
+		Emails:           c.Emails,
+		HashRoutes:       c.HashRoutes,
+		OpenSearch:       c.OpenSearch,
+		RobotsDisallowed: c.RobotsDisallowed,
+		RedirectLoops:    c.RedirectLoops,
+		BrokenLinks:      c.BrokenLinks(),
+		Parameters:       params,
+		ParamEndpoints:   paramEndpoints,
+		SitemapCoverage:  c.SitemapCoverage(),
+		ProtectedHosts:   c.Protected,
+		TimeCapped:       c.TimeCapped,
+		MixedContent:     c.MixedContent,
+		Subresources:     c.Subresources,
+		Discrepancies:    c.Discrepancies(),
+		Count:            len(c.Results),
+	}
+	if c.Config.Canonical {
+		data.Metadata = nil
+		sort.Strings(data.Results)
+		data.Details = canonicalResults(data.Details)
+		data.Sensitive = canonicalResults(data.Sensitive)
+		data.WebSockets = canonicalResults(data.WebSockets)
+		data.Emails = canonicalResults(data.Emails)
+		data.HashRoutes = canonicalResults(data.HashRoutes)
+		data.OpenSearch = canonicalSearchEndpoints(data.OpenSearch)
+		data.RobotsDisallowed = canonicalResults(data.RobotsDisallowed)
+		data.RedirectLoops = canonicalResults(data.RedirectLoops)
+		data.MixedContent = canonicalFindings(data.MixedContent)
+		data.Subresources = canonicalSubresources(data.Subresources)
+		data.Forms = canonicalForms(data.Forms)
 	}
 	file, err := os.Create(c.Config.OutputPath)
 	if err != nil {
@@ -366,24 +1456,117 @@ func (c *Crawler) SaveJSON() error {
 	return encoder.Encode(data)
 }
 
+// canonicalResults returns a sorted copy of results without the fields that
+// depend on crawl timing (timestamps, and the referrer that happened to be
+// crawled first), so unchanged sites produce byte-identical exports.
+func canonicalResults(results []Result) []Result {
+	out := make([]Result, len(results))
+	for i, r := range results {
+		r.DiscoveredAt = time.Time{}
+		r.FoundOn = ""
+		out[i] = r
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].URL < out[j].URL
+	})
+	return out
+}
+
+// canonicalFindings is canonicalResults for per-page findings, where the
+// page is part of the finding and is kept.
+func canonicalFindings(results []Result) []Result {
+	out := make([]Result, len(results))
+	for i, r := range results {
+		r.DiscoveredAt = time.Time{}
+		out[i] = r
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FoundOn != out[j].FoundOn {
+			return out[i].FoundOn < out[j].FoundOn
+		}
+		return out[i].URL < out[j].URL
+	})
+	return out
+}
+
+func canonicalForms(forms []Form) []Form {
+	out := make([]Form, len(forms))
+	for i, f := range forms {
+		f.FoundOn = ""
+		out[i] = f
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Action != out[j].Action {
+			return out[i].Action < out[j].Action
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+func canonicalSubresources(resources []Subresource) []Subresource {
+	out := make([]Subresource, len(resources))
+	for i, sr := range resources {
+		sr.FoundOn = ""
+		out[i] = sr
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].URL != out[j].URL {
+			return out[i].URL < out[j].URL
+		}
+		return out[i].Integrity < out[j].Integrity
+	})
+	return out
+}
+
+func canonicalSearchEndpoints(endpoints []SearchEndpoint) []SearchEndpoint {
+	out := make([]SearchEndpoint, len(endpoints))
+	for i, ep := range endpoints {
+		ep.FoundOn = ""
+		out[i] = ep
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Template != out[j].Template {
+			return out[i].Template < out[j].Template
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
 type treeNode struct {
-	Name     string               `json:"name"`
-	Children map[string]*treeNode `json:"children,omitempty"`
+	Name        string               `json:"name"`
+	URL         string               `json:"url"`
+	Status      int                  `json:"status,omitempty"` // Set on nodes that are results
+	ContentType string               `json:"content_type,omitempty"`
+	Crawled     bool                 `json:"crawled"` // The page itself was fetched
+	Descendants int                  `json:"descendants"`
+	Children    map[string]*treeNode `json:"children,omitempty"`
 }
 
-func newTreeNode(name string) *treeNode {
+func newTreeNode(name, u string) *treeNode {
 	return &treeNode{
 		Name:     name,
+		URL:      u,
 		Children: make(map[string]*treeNode),
 	}
 }
 
-// PrintTree outputs the internal directory structure tree to stdout.
+// countDescendants fills in Descendants for node and everything below it.
+func (n *treeNode) countDescendants() int {
+	n.Descendants = 0
+	for _, child := range n.Children {
+		n.Descendants += 1 + child.countDescendants()
+	}
+	return n.Descendants
+}
+
+// PrintTree outputs the internal directory structure tree to the console.
 func (c *Crawler) PrintTree() {
 	if !c.Config.ShowTree {
 		return
 	}
-	fmt.Printf("\n%s\n%s\n", color.MagentaString("=== Site Tree ==="), c.Config.TargetURL)
+	fmt.Fprintf(c.console(), "\n%s\n%s\n", color.MagentaString("=== Site Tree ==="), c.Config.TargetURL)
 
 	root := c.buildTree()
 	c.printRecursive(root, "")
@@ -402,7 +1585,7 @@ func (c *Crawler) printRecursive(node *treeNode, prefix string) {
 		if isLast {
 			connector = "└── "
 		}
-		fmt.Printf("%s%s%s\n", prefix, connector, name)
+		fmt.Fprintf(c.console(), "%s%s%s\n", prefix, connector, name)
 
 		newPrefix := prefix + "│   "
 		if isLast {
@@ -414,15 +1597,27 @@ func (c *Crawler) printRecursive(node *treeNode, prefix string) {
 
 func (c *Crawler) buildTree() *treeNode {
 	rootURL, _ := url.Parse(c.Config.TargetURL)
-	root := newTreeNode("/")
+	origin := rootURL.Scheme + "://" + rootURL.Host
+	root := newTreeNode("/", origin+"/")
 
-	urls := append([]string{c.Config.TargetURL}, c.Results...)
-	for _, uStr := range urls {
+	results := append([]Result{{URL: c.Config.TargetURL}}, c.Results...)
+	for _, r := range results {
+		uStr := r.URL
 		u, err := url.Parse(uStr)
-		if err != nil || u.Host != rootURL.Host {
+		if err != nil || !c.inTargets(u) {
 			continue
 		}
 
+		// Other hosts in scope, or merged targets, hang below the root as a node of their own
+		current, base := root, origin
+		if u.Host != rootURL.Host {
+			base = u.Scheme + "://" + u.Host
+			if _, exists := root.Children[u.Host]; !exists {
+				root.Children[u.Host] = newTreeNode(u.Host, base+"/")
+			}
+			current = root.Children[u.Host]
+		}
+
 		path := u.Path
 		if path == "" {
 			path = "/"
@@ -434,28 +1629,33 @@ func (c *Crawler) buildTree() *treeNode {
 		}
 
 		parts := strings.Split(path, "/")
-		current := root
-
 		for i, part := range parts {
 			if part == "" {
 				continue
 			}
 			name := part
-			if i == len(parts)-1 {
+			if i == len(parts)-1 && !c.Config.QueryAsChild {
 				name += suffix
 			}
 			if _, exists := current.Children[name]; !exists {
-				current.Children[name] = newTreeNode(name)
+				current.Children[name] = newTreeNode(name, base+strings.Join(parts[:i+1], "/"))
 			}
 			current = current.Children[name]
 		}
 
-		if path == "/" && suffix != "" {
-			name := "?" + u.RawQuery
-			if _, exists := root.Children[name]; !exists {
-				root.Children[name] = newTreeNode(name)
+		// Queries on the root, or all of them with QueryAsChild, hang
+		// below their path as a node of their own
+		if suffix != "" && (path == "/" || c.Config.QueryAsChild) {
+			if _, exists := current.Children[suffix]; !exists {
+				current.Children[suffix] = newTreeNode(suffix, uStr)
 			}
+			current = current.Children[suffix]
 		}
+		current.URL = uStr
+		current.Status = r.Status
+		current.ContentType = r.ContentType
+		_, current.Crawled = c.crawled.Load(uStr)
 	}
+	root.countDescendants()
 	return root
 }
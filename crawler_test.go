@@ -0,0 +1,659 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// crawlTest runs a crawl with cfg and fails the test if it can't start.
+func crawlTest(t *testing.T, cfg Config) *Crawler {
+	t.Helper()
+	if cfg.MaxDepth == 0 {
+		cfg.MaxDepth = 2
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c.Start(); err != nil {
+		t.Fatalf("Start(%s): %v", cfg.TargetURL, err)
+	}
+	return c
+}
+
+func resultURLs(c *Crawler) []string {
+	urls := make([]string, len(c.Results))
+	for i, r := range c.Results {
+		urls[i] = r.URL
+	}
+	return urls
+}
+
+func TestCrawlAcceptHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			return
+		}
+		// Content negotiation: JSON unless HTML is asked for
+		if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"status":"ok"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="/page">page</a>`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"default", nil, true},
+		{"overridden", map[string]string{"Accept": "application/json"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := crawlTest(t, Config{TargetURL: srv.URL, Headers: tt.headers})
+			if got := slices.Contains(resultURLs(c), srv.URL+"/page"); got != tt.want {
+				t.Errorf("found /page = %v, want %v (results %v)", got, tt.want, resultURLs(c))
+			}
+		})
+	}
+}
+
+func TestBearerTokenScope(t *testing.T) {
+	var leaked atomic.Bool
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			leaked.Store(true)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer external.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/out" {
+			http.Redirect(w, r, external.URL+"/redirected", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<a href="%s/linked">ext</a> <a href="/out">out</a>`, external.URL)
+	}))
+	defer srv.Close()
+
+	crawlTest(t, Config{
+		TargetURL:    srv.URL,
+		TokenRefresh: func() (string, error) { return "secret", nil },
+	})
+	if leaked.Load() {
+		t.Error("bearer token sent to an external host")
+	}
+}
+
+func TestCrawlEventStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<a href="/events">events</a> <a href="/page">page</a>`)
+			return
+		}
+		// Never ends on its own and has no Content-Length
+		w.Header().Set("Content-Type", "text/event-stream")
+		if r.Method == http.MethodHead {
+			return
+		}
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+				fmt.Fprint(w, "data: tick\n\n")
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer srv.Close()
+
+	done := make(chan *Crawler)
+	go func() { done <- crawlTest(t, Config{TargetURL: srv.URL}) }()
+	select {
+	case c := <-done:
+		if !slices.Contains(resultURLs(c), srv.URL+"/events") {
+			t.Errorf("stream not reported, results %v", resultURLs(c))
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("crawl blocked on the event stream")
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"custom pattern", Config{CustomPatterns: []string{`ok`, `(`}}},
+		{"session ID pattern", Config{SessionIDPatterns: []string{`[`}}},
+		{"extraction scope", Config{ExtractWithin: []string{`main >`}}},
+		{"pagination pattern", Config{PaginationPatterns: []string{`*next`}}},
+		{"pagination selector", Config{PaginationSelectors: []string{`a[`}}},
+		{"include", Config{IncludePatterns: []string{`/blog/`, `(?<x)`}}},
+		{"exclude", Config{ExcludePatterns: []string{`\`}}},
+		{"auth and token", Config{AuthScheme: "basic", TokenRefresh: func() (string, error) { return "", nil }}},
+		{"render and private IPs", Config{Render: true, BlockPrivateIPs: true}},
+		{"cookie pattern", Config{Cookies: []CookieRule{{Pattern: `(`}}}},
+		{"proxy", Config{ProxyURL: "ftp://proxy.test"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New accepted an invalid config")
+			}
+		})
+	}
+}
+
+func TestAuthSchemeScope(t *testing.T) {
+	tests := []struct {
+		scheme string
+		want   string // Authorization prefix expected by the target
+	}{
+		{"basic", "Basic "},
+		{"ntlm", "NTLM "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			var leaked, authed atomic.Bool
+			external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "" {
+					leaked.Store(true)
+				}
+			}))
+			defer external.Close()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth := r.Header.Get("Authorization")
+				if strings.HasPrefix(auth, tt.want) {
+					authed.Store(true)
+				} else if auth != "" {
+					t.Errorf("target got Authorization %q, want %s", auth, tt.want)
+				}
+				if tt.scheme == "ntlm" && auth == "" {
+					// Accepting the negotiate message is enough, the handshake needn't complete
+					w.Header().Set("WWW-Authenticate", "NTLM")
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Header().Set("Content-Type", "text/html")
+				fmt.Fprintf(w, `<a href="%s/linked">ext</a>`, external.URL)
+			}))
+			defer srv.Close()
+
+			crawlTest(t, Config{TargetURL: srv.URL, AuthScheme: tt.scheme, AuthUser: `CORP\user`, AuthPassword: "pass"})
+			if !authed.Load() {
+				t.Errorf("target never got %scredentials", tt.want)
+			}
+			if leaked.Load() {
+				t.Error("credentials sent to an external host")
+			}
+		})
+	}
+}
+
+func TestCrawlSchemelessTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="/page">page</a>`)
+	}))
+	defer srv.Close()
+
+	// The "://" in the query must not be taken for the target's scheme
+	host := strings.TrimPrefix(srv.URL, "http://")
+	c := crawlTest(t, Config{TargetURL: host + "/?next=https://other.test/", DefaultScheme: "http"})
+	if !slices.Contains(resultURLs(c), srv.URL+"/page") {
+		t.Errorf("schemeless target not crawled, results %v", resultURLs(c))
+	}
+}
+
+func TestCrawlGzipBodies(t *testing.T) {
+	dir := t.TempDir()
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	fmt.Fprint(zw, `<a href="from-gzip.html">g</a>`)
+	zw.Close()
+	files := map[string][]byte{
+		"index.html":       []byte(`<a href="archived.html.gz">a</a> <a href="plain.gz">p</a> <a href="/etc/hostname">x</a>`),
+		"archived.html.gz": gz.Bytes(),
+		"plain.gz":         []byte(`<a href="from-plain.html">p</a>`),
+		"from-gzip.html":   nil,
+		"from-plain.html":  nil,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	tests := []struct {
+		name, target, base string
+	}{
+		{"http", srv.URL + "/index.html", srv.URL},
+		{"offline", "file://" + filepath.ToSlash(dir) + "/index.html", "file://" + filepath.ToSlash(dir)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls := resultURLs(crawlTest(t, Config{TargetURL: tt.target, MaxDepth: 3}))
+			for _, want := range []string{"/from-gzip.html", "/from-plain.html"} {
+				if !slices.Contains(urls, tt.base+want) {
+					t.Errorf("%s not found, results %v", want, urls)
+				}
+			}
+			if tt.name == "offline" && slices.Contains(urls, "file:///etc/hostname") {
+				t.Error("offline crawl left its directory")
+			}
+		})
+	}
+}
+
+func TestValidateLinkHeadReset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<p>ok</p>`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		valid  bool
+		status int
+	}{
+		{"/page", true, http.StatusOK},
+		{"/missing", false, http.StatusNotFound},
+	}
+	c, err := New(Config{TargetURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		v := c.validateLink(srv.URL + tt.path)
+		if v.Valid != tt.valid || v.Status != tt.status {
+			t.Errorf("validateLink(%s) = %+v, want %d from the GET fallback", tt.path, v, tt.status)
+		}
+	}
+}
+
+func TestCrawlBreakerRequeue(t *testing.T) {
+	var failures atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<a href="/flaky">flaky</a> <a href="/a">a</a> <a href="/b">b</a>`)
+		case "/flaky":
+			// Trips the breaker, the pages queued behind it are refused
+			if failures.Add(1) <= 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, `<a href="/behind">behind</a>`)
+		default:
+			fmt.Fprintf(w, `<a href="%s/child">child</a>`, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := crawlTest(t, Config{TargetURL: srv.URL, MaxDepth: 3, CrawlWorkers: 1, BreakerThreshold: 1, BreakerCooldown: 50 * time.Millisecond})
+	for _, want := range []string{"/a/child", "/b/child"} {
+		if !slices.Contains(resultURLs(c), srv.URL+want) {
+			t.Errorf("%s lost to the open breaker, results %v", want, resultURLs(c))
+		}
+	}
+}
+
+func TestCookieRuleScope(t *testing.T) {
+	var leaked, sent atomic.Bool
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err == nil {
+			leaked.Store(true)
+		}
+	}))
+	defer external.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err == nil {
+			sent.Store(true)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<a href="%s/linked">ext</a>`, external.URL)
+	}))
+	defer srv.Close()
+
+	rule, err := ParseCookieRule("session=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawlTest(t, Config{TargetURL: srv.URL, Cookies: []CookieRule{rule}})
+	if !sent.Load() {
+		t.Error("cookie not sent to the target")
+	}
+	if leaked.Load() {
+		t.Error("cookie without a pattern sent to an external host")
+	}
+}
+
+func TestRobotsSeedsAndProbes(t *testing.T) {
+	var hit atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /admin/\nDisallow: /.env\n")
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<p>home</p>`)
+		default:
+			hit.Store(r.Method + " " + r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := crawlTest(t, Config{
+		TargetURL:           srv.URL,
+		RespectRobots:       true,
+		Seeds:               []Seed{{URL: "/admin/search", Method: "POST", Body: "q=x"}},
+		ProbeSensitiveFiles: true,
+		SensitiveFiles:      []string{".env"},
+	})
+	if v := hit.Load(); v != nil {
+		t.Errorf("disallowed path requested: %s", v)
+	}
+	if len(c.RobotsDisallowed) != 2 {
+		t.Errorf("robots_disallowed = %v, want the seed and the probe", c.RobotsDisallowed)
+	}
+}
+
+func TestHeaderScope(t *testing.T) {
+	var leaked atomic.Bool
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "" || r.Header.Get("Authorization") != "" {
+			leaked.Store(true)
+		}
+	}))
+	defer external.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/out" {
+			http.Redirect(w, r, external.URL+"/redirected", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<a href="%s/linked">ext</a> <a href="/out">out</a>`, external.URL)
+	}))
+	defer srv.Close()
+
+	crawlTest(t, Config{TargetURL: srv.URL, Headers: map[string]string{"X-Api-Key": "secret", "Authorization": "Token secret"}})
+	if leaked.Load() {
+		t.Error("custom headers sent to an external host")
+	}
+}
+
+func TestSitemapOnlyInternal(t *testing.T) {
+	var probed atomic.Bool
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probed.Store(true)
+	}))
+	defer external.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sitemap.xml" {
+			fmt.Fprintf(w, `<urlset><url><loc>%s/external</loc></url></urlset>`, external.URL)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<p>home</p>`)
+	}))
+	defer srv.Close()
+
+	crawlTest(t, Config{TargetURL: srv.URL, UseSitemap: true, OnlyInternal: true})
+	if probed.Load() {
+		t.Error("external sitemap URL probed despite OnlyInternal")
+	}
+}
+
+func TestReportScope(t *testing.T) {
+	c, err := New(Config{TargetURL: "https://www.example.com/", ScopeMode: ScopeSubdomains, OutputStyle: "relative"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Results = []Result{
+		{URL: "https://www.example.com/docs/a?lang=fr", Status: 200},
+		{URL: "https://api.example.com/v1/users?page=2", Status: 200},
+		{URL: "https://other.test/blog/post?ref=x", Status: 200},
+	}
+
+	var dirs []string
+	for _, ds := range c.directoryStats() {
+		dirs = append(dirs, ds.Directory)
+	}
+	slices.Sort(dirs)
+	if want := []string{"/docs/", "/v1/"}; !slices.Equal(dirs, want) {
+		t.Errorf("directoryStats = %v, want %v", dirs, want)
+	}
+	if names, _ := c.Parameters(); !slices.Equal(names, []string{"lang", "page"}) {
+		t.Errorf("Parameters = %v, want [lang page]", names)
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"https://www.example.com/docs/a", "/docs/a"},
+		{"https://api.example.com/v1/users", "//api.example.com/v1/users"},
+		{"https://other.test/blog/post", "https://other.test/blog/post"},
+	}
+	for _, tt := range tests {
+		if got := c.formatResult(tt.in); got != tt.want {
+			t.Errorf("formatResult(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	dirs = c.internalDirectories()
+	want := []string{"https://api.example.com/", "https://api.example.com/v1/", "https://www.example.com/", "https://www.example.com/docs/"}
+	if !slices.Equal(dirs, want) {
+		t.Errorf("internalDirectories = %v, want %v", dirs, want)
+	}
+
+	if _, err := New(Config{ScopeMode: "subdomain"}); err == nil {
+		t.Error("New accepted an unknown scope mode")
+	}
+}
+
+func TestConsoleWriter(t *testing.T) {
+	var console bytes.Buffer
+	c, err := New(Config{TargetURL: "https://example.com/", DirStats: true, Console: &console, OutputBuffer: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Results = []Result{{URL: "https://example.com/docs/a", Status: 200}}
+
+	c.PrintDirStats()
+	c.enableInsecure()
+	c.printf("[%d] %s\n", 200, "https://example.com/docs/a")
+	c.Flush()
+
+	for _, want := range []string{"=== Directories ===", "[WRN] SSL verification disabled", "[200] https://example.com/docs/a"} {
+		if !strings.Contains(console.String(), want) {
+			t.Errorf("console output missing %q:\n%s", want, console.String())
+		}
+	}
+}
+
+func TestCrawlCanonical(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		canonical := map[string]string{
+			"/a":          "/a-canon",
+			"/syndicated": "https://other.test/original",
+			"/b":          "/slow",
+		}[r.URL.Path]
+		if canonical != "" {
+			fmt.Fprintf(w, `<link rel="canonical" href="%s">`, canonical)
+		}
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<a href="/a">a</a> <a href="/syndicated">s</a> <a href="/slow">slow</a> <a href="/b">b</a>`)
+		case "/slow":
+			// Still being fetched when /b declares it canonical
+			if r.Method == http.MethodGet {
+				time.Sleep(300 * time.Millisecond)
+			}
+		case "/b":
+			fmt.Fprint(w, `<a href="/b-child">child</a>`)
+		}
+	}))
+	defer srv.Close()
+
+	var emitted bytes.Buffer
+	c := crawlTest(t, Config{TargetURL: srv.URL, MaxDepth: 3, HonorCanonical: true, Sinks: []Sink{NewJSONSink(&emitted)}})
+	urls := resultURLs(c)
+
+	var streamed []string
+	for line := range strings.Lines(emitted.String()) {
+		var r Result
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		streamed = append(streamed, r.URL)
+	}
+	slices.Sort(urls)
+	slices.Sort(streamed)
+	if !slices.Equal(urls, streamed) {
+		t.Errorf("sinks got %v, results are %v", streamed, urls)
+	}
+
+	for _, want := range []string{"/a-canon", "/syndicated", "/slow", "/b-child"} {
+		if !slices.Contains(urls, srv.URL+want) {
+			t.Errorf("%s missing, results %v", want, urls)
+		}
+	}
+	for _, unwanted := range []string{"/a", "/b", "https://other.test/original"} {
+		if slices.Contains(urls, srv.URL+unwanted) || slices.Contains(urls, unwanted) {
+			t.Errorf("%s reported, results %v", unwanted, urls)
+		}
+	}
+	if _, visited := c.Visited.Load("https://other.test/original"); visited {
+		t.Error("off-site canonical marked visited")
+	}
+}
+
+func TestBlockPrivateThroughProxy(t *testing.T) {
+	var proxied atomic.Bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(true)
+	}))
+	defer proxy.Close()
+
+	c, err := New(Config{TargetURL: "http://93.184.215.14/", ProxyURL: proxy.URL, BlockPrivateIPs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sitemap, template, OpenSearch and pagination URLs all go through do
+	for _, u := range []string{"http://169.254.169.254/latest/meta-data/", "http://10.0.0.1/opensearch.xml"} {
+		req, err := c.newRequest("GET", u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.do(c.FastClient, req); !errors.Is(err, errBlockedHost) {
+			t.Errorf("do(%s) = %v, want %v", u, err, errBlockedHost)
+		}
+	}
+	if proxied.Load() {
+		t.Error("private address requested through the proxy")
+	}
+}
+
+func TestWebhookCloseInterrupted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Never answers, like an unreachable endpoint
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{TargetURL: "http://example.test/", WebhookURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	s := newWebhookSink(c)
+	for i := range 50 {
+		s.Write(Result{URL: fmt.Sprintf("http://example.test/%d", i)})
+	}
+	cancel()
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close kept sending after the crawl was interrupted")
+	}
+	if lost := s.lost.Load(); lost != 50 {
+		t.Errorf("lost = %d, want 50", lost)
+	}
+}
+
+func TestDiscrepanciesFilteredLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="https://other.test/page">ext</a> <a href="/private/admin">excluded</a> <a href="/ok">ok</a>`)
+	}))
+	defer srv.Close()
+
+	c := crawlTest(t, Config{TargetURL: srv.URL, OnlyInternal: true, ExcludePatterns: []string{`/private/`}, Discrepancies: true})
+	reasons := make(map[string]string)
+	for _, d := range c.Discrepancies() {
+		reasons[d.URL] = d.Reason
+	}
+	want := map[string]string{
+		"https://other.test/page":  "external link excluded by OnlyInternal",
+		srv.URL + "/private/admin": "excluded by include/exclude patterns",
+	}
+	for u, reason := range want {
+		if reasons[u] != reason {
+			t.Errorf("discrepancy for %s = %q, want %q", u, reasons[u], reason)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import "sort"
+
+// Discrepancy is a URL the crawler visited without reporting it, with the
+// reason it was left out.
+type Discrepancy struct {
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// skipLink marks a link left out before validation as visited, recording
+// why, so it still shows up in Discrepancies.
+func (c *Crawler) skipLink(u, reason string) {
+	if _, loaded := c.Visited.LoadOrStore(u, true); !loaded {
+		c.filterOut(u, reason)
+	}
+}
+
+// filterOut records why a visited URL produces no result. Only the first
+// reason given for a URL is kept.
+func (c *Crawler) filterOut(u, reason string) {
+	if c.Config.Discrepancies {
+		c.filtered.LoadOrStore(u, reason)
+	}
+}
+
+// Discrepancies reconciles Visited with the reported findings: every
+// visited URL missing from Results, Sensitive, WebSockets, RedirectLoops and
+// Emails is listed with its reason, sorted by URL.
+func (c *Crawler) Discrepancies() []Discrepancy {
+	if !c.Config.Discrepancies {
+		return nil
+	}
+
+	reported := make(map[string]bool)
+	c.resultsMu.Lock()
+	for _, list := range [][]Result{c.Results, c.Sensitive, c.WebSockets, c.RedirectLoops, c.HashRoutes} {
+		for _, r := range list {
+			reported[r.URL] = true
+		}
+	}
+	for _, r := range c.Emails {
+		reported["mailto:"+r.URL] = true
+	}
+	c.resultsMu.Unlock()
+
+	var out []Discrepancy
+	c.Visited.Range(func(k, _ any) bool {
+		u := k.(string)
+		if reported[u] {
+			return true
+		}
+		reason := "no result recorded"
+		if v, ok := c.filtered.Load(u); ok {
+			reason = v.(string)
+		} else if u == c.Config.TargetURL {
+			reason = "crawl target"
+		}
+		out = append(out, Discrepancy{URL: u, Reason: reason})
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
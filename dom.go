@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// compileSelectors compiles CSS selectors, reporting the first invalid one.
+func compileSelectors(selectors []string) ([]cascadia.Sel, error) {
+	compiled := make([]cascadia.Sel, 0, len(selectors))
+	for _, s := range selectors {
+		sel, err := cascadia.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+		}
+		compiled = append(compiled, sel)
+	}
+	return compiled, nil
+}
+
+// scopeContent keeps only the parts of an HTML document matched by one of
+// the selectors, rendered back to markup for extraction. Rendering escapes
+// attribute values, so entities are decoded again to keep URLs intact.
+// Content that fails to parse is returned unchanged.
+func scopeContent(content string, selectors []cascadia.Sel) string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	var buf bytes.Buffer
+	seen := make(map[*html.Node]bool)
+	for _, sel := range selectors {
+		for _, n := range cascadia.QueryAll(doc, sel) {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			html.Render(&buf, n)
+			buf.WriteByte('\n')
+		}
+	}
+	return html.UnescapeString(buf.String())
+}
+
+// linkAttrs lists, per element, the attributes holding URLs for ExtractDOM.
+var linkAttrs = map[string][]string{
+	"a":      {"href"},
+	"link":   {"href"},
+	"script": {"src"},
+	"img":    {"src", "srcset"},
+	"source": {"src", "srcset"},
+	"iframe": {"src"},
+	"form":   {"action"},
+}
+
+// ExtractDOM tokenizes an HTML document and returns the unique URLs found in
+// link-bearing attributes. Unlike Extract it handles any quoting or line
+// breaks and ignores URL-like strings in scripts and text, except the
+// WebSocket URLs of inline scripts, which have no attribute to be found in.
+func ExtractDOM(content string, extra ...*regexp.Regexp) []string {
+	links, _ := extractDOM(content, -1, extra...)
+	return links
+}
+
+// extractDOM is ExtractDOM keeping at most limit candidates, see extractJSON.
+func extractDOM(content string, limit int, extra ...*regexp.Regexp) ([]string, bool) {
+	links := linkSet{limit: limit}
+	z := html.NewTokenizer(strings.NewReader(content))
+	inScript := false
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt == html.TextToken && inScript {
+			for _, u := range wsRegex.FindAllString(string(z.Text()), limit) {
+				links.add(u)
+			}
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			inScript = false
+			continue
+		}
+		name, hasAttr := z.TagName()
+		inScript = tt == html.StartTagToken && string(name) == "script"
+		want, ok := linkAttrs[string(name)]
+		for ok && hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			switch {
+			case !slices.Contains(want, string(key)):
+			case string(key) == "srcset":
+				for _, u := range srcsetURLs(string(val)) {
+					links.add(u)
+				}
+			default:
+				links.add(strings.TrimSpace(string(val)))
+			}
+		}
+	}
+	links.addPatterns(content, extra)
+	return links.found, links.truncated
+}
+
+// srcsetURLs splits a srcset attribute into its image URLs, dropping the
+// width and density descriptors. As in the HTML parsing algorithm, a URL
+// runs until whitespace, so commas inside URLs are kept, and a descriptor
+// list ends at the first comma outside parentheses.
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\f\r,")
+		if s == "" {
+			return urls
+		}
+		end := strings.IndexAny(s, " \t\n\f\r")
+		if end < 0 {
+			end = len(s)
+		}
+		u := s[:end]
+		s = s[end:]
+		if trimmed := strings.TrimRight(u, ","); trimmed != u {
+			// A trailing comma ends the candidate, there is no descriptor
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, u)
+		s = skipDescriptors(s)
+	}
+}
+
+// skipDescriptors returns the rest of a srcset after the descriptors of a
+// candidate, from the comma that ends them.
+func skipDescriptors(s string) string {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth = max(depth-1, 0)
+		case ',':
+			if depth == 0 {
+				return s[i:]
+			}
+		}
+	}
+	return ""
+}
+
+// Form is an HTML form found during the crawl, with its resolved target
+// and the names of its fields.
+type Form struct {
+	Method  string   `json:"method"`
+	Action  string   `json:"action"`
+	Fields  []string `json:"fields"`
+	FoundOn string   `json:"found_on,omitempty"`
+}
+
+// ExtractForms tokenizes an HTML document and returns its forms. Actions are
+// returned as written in the page; an empty action means the page itself.
+func ExtractForms(content string) []Form {
+	var forms []Form
+	var current *Form
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		name, hasAttr := z.TagName()
+		tag := string(name)
+
+		if tt == html.EndTagToken {
+			if tag == "form" && current != nil {
+				forms = append(forms, *current)
+				current = nil
+			}
+			continue
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		attrs := make(map[string]string)
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			attrs[string(key)] = string(val)
+		}
+
+		switch tag {
+		case "form":
+			if current != nil {
+				forms = append(forms, *current)
+			}
+			method := strings.ToUpper(strings.TrimSpace(attrs["method"]))
+			if method == "" {
+				method = "GET"
+			}
+			current = &Form{Method: method, Action: strings.TrimSpace(attrs["action"]), Fields: []string{}}
+		case "input", "select", "textarea", "button":
+			if current != nil && attrs["name"] != "" && !slices.Contains(current.Fields, attrs["name"]) {
+				current.Fields = append(current.Fields, attrs["name"])
+			}
+		}
+	}
+	if current != nil {
+		forms = append(forms, *current)
+	}
+	return forms
+}
+
+// CanonicalURL returns the href of the document's <link rel="canonical">, or
+// "" when it declares none. Scanning stops at the body.
+func CanonicalURL(content string) string {
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return ""
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		switch string(name) {
+		case "body":
+			return ""
+		case "link":
+		default:
+			continue
+		}
+
+		var rel, href string
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			switch string(key) {
+			case "rel":
+				rel = string(val)
+			case "href":
+				href = string(val)
+			}
+		}
+		if slices.Contains(strings.Fields(strings.ToLower(rel)), "canonical") {
+			return strings.TrimSpace(href)
+		}
+	}
+}
+
+// InsecureSubresources returns the plain http:// URLs an HTML document
+// loads as sub-resources: src attributes and <link href> such as
+// stylesheets. Navigational <a href> links are not mixed content.
+func InsecureSubresources(content string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return found
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		isLink := string(name) == "link"
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			if string(key) != "src" && !(isLink && string(key) == "href") {
+				continue
+			}
+			v := strings.TrimSpace(string(val))
+			if len(v) > 7 && strings.EqualFold(v[:7], "http://") && !seen[v] {
+				seen[v] = true
+				found = append(found, v)
+			}
+		}
+	}
+}
+
+// Subresource is a script or stylesheet reference and its Subresource
+// Integrity hash, empty when the resource isn't pinned.
+type Subresource struct {
+	URL       string `json:"url"`
+	Integrity string `json:"integrity"`
+	FoundOn   string `json:"found_on,omitempty"`
+}
+
+// ExtractSubresources returns the scripts and <link> resources (stylesheets,
+// preloads) of an HTML document with their integrity attribute.
+func ExtractSubresources(content string) []Subresource {
+	var found []Subresource
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return found
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		tag := string(name)
+		if tag != "script" && tag != "link" {
+			continue
+		}
+
+		attrs := make(map[string]string)
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			attrs[string(key)] = string(val)
+		}
+		ref := attrs["src"]
+		if tag == "link" {
+			rel := strings.Fields(strings.ToLower(attrs["rel"]))
+			if !slices.Contains(rel, "stylesheet") && !slices.Contains(rel, "preload") && !slices.Contains(rel, "modulepreload") {
+				continue
+			}
+			ref = attrs["href"]
+		}
+		if ref = strings.TrimSpace(ref); ref != "" {
+			found = append(found, Subresource{URL: ref, Integrity: strings.TrimSpace(attrs["integrity"])})
+		}
+	}
+}
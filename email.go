@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// addEmail records the address of a mailto: link the first time it is seen.
+func (c *Crawler) addEmail(u *url.URL, foundOn string) {
+	addr, _, _ := strings.Cut(u.Opaque, "?")
+	if decoded, err := url.PathUnescape(addr); err == nil {
+		addr = decoded
+	}
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	if !strings.Contains(addr, "@") {
+		return
+	}
+	if _, loaded := c.Visited.LoadOrStore("mailto:"+addr, true); loaded {
+		return
+	}
+	c.printf("[%s] %s\n", color.MagentaString("EML"), addr)
+	c.resultsMu.Lock()
+	c.Emails = append(c.Emails, Result{
+		URL:          addr,
+		FoundOn:      foundOn,
+		DiscoveredAt: time.Now(),
+	})
+	c.resultsMu.Unlock()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// estimate fetches the target page alone and reports how many internal and
+// external links it yields, with the page count each depth could reach if
+// every page were as well linked. Links are not validated.
+func (c *Crawler) estimate(target string) error {
+	req, err := c.newRequest("GET", target)
+	if err != nil {
+		return err
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", defaultAccept)
+	}
+
+	started := time.Now()
+	resp, err := c.do(c.Client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("target returned %s", resp.Status)
+	}
+
+	links, _, err := c.readLinks(resp, req.URL, started)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	internal, external := 0, 0
+	for _, l := range links {
+		res, err := req.URL.Parse(l)
+		if err != nil || (res.Scheme != "http" && res.Scheme != "https") {
+			continue
+		}
+		abs := normalizeURL(res)
+		if seen[abs] || abs == target {
+			continue
+		}
+		seen[abs] = true
+		if !c.inScope(res, req.URL) {
+			external++
+		} else {
+			internal++
+		}
+	}
+
+	fmt.Fprintf(c.console(), "\n%s\n", color.MagentaString("=== Estimate ==="))
+	fmt.Fprintf(c.console(), "internal=%d external=%d\n", internal, external)
+	pages := 1.0
+	for d := 1; d <= c.Config.MaxDepth; d++ {
+		pages *= float64(internal)
+		fmt.Fprintf(c.console(), "depth %d: up to %s pages\n", d, formatEstimate(pages))
+	}
+	return nil
+}
+
+func formatEstimate(n float64) string {
+	if n >= 1e9 || math.IsInf(n, 0) {
+		return fmt.Sprintf("%.1e", n)
+	}
+	return fmt.Sprintf("%.0f", n)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -9,32 +11,142 @@ var (
 	urlRegex  = regexp.MustCompile(`https?://[a-zA-Z0-9\-\.]+\.[a-zA-Z]{2,}(?:/[^"'\s<>` + "`" + `]*)?`)
 	pathRegex = regexp.MustCompile(`["'](\.?\.?/[^"'\s<>` + "`" + `]+)["']`)
 	attrRegex = regexp.MustCompile(`(href|src)=["']([^"']+)["']`)
+	wsRegex   = regexp.MustCompile(`wss?://[a-zA-Z0-9\-\.]+(?::[0-9]+)?(?:/[^"'\s<>` + "`" + `]*)?`)
 )
 
 // Extract parses the provided content string and returns a slice of unique URLs found.
 // It uses regex to identify full URLs, absolute paths, and relative paths in attributes.
-func Extract(content string) []string {
-	seen := make(map[string]bool)
-	var found []string
-	add := func(s string) {
-		if !seen[s] && len(s) > 1 && !strings.ContainsAny(s, "\n ") {
-			found = append(found, s)
-			seen[s] = true
-		}
+// Extra patterns are applied afterwards, taking capture group 1 (or the whole
+// match when there is no group) as the URL.
+func Extract(content string, extra ...*regexp.Regexp) []string {
+	links, _ := ExtractN(content, -1, extra...)
+	return links
+}
+
+// ExtractN is Extract with each regex stopping after limit matches, so a
+// huge minified file can't produce millions of candidates. A negative limit
+// means no limit. truncated reports whether any regex hit the limit.
+func ExtractN(content string, limit int, extra ...*regexp.Regexp) (links []string, truncated bool) {
+	var set linkSet
+	hit := func(n int) {
+		truncated = truncated || (limit >= 0 && n >= limit)
 	}
 
-	for _, m := range urlRegex.FindAllString(content, -1) {
-		add(m)
+	matches := urlRegex.FindAllString(content, limit)
+	hit(len(matches))
+	for _, m := range matches {
+		set.add(m)
+	}
+	matches = wsRegex.FindAllString(content, limit)
+	hit(len(matches))
+	for _, m := range matches {
+		set.add(m)
 	}
-	for _, m := range pathRegex.FindAllStringSubmatch(content, -1) {
+	groups := pathRegex.FindAllStringSubmatch(content, limit)
+	hit(len(groups))
+	for _, m := range groups {
 		if len(m) > 1 {
-			add(m[1])
+			set.add(m[1])
 		}
 	}
-	for _, m := range attrRegex.FindAllStringSubmatch(content, -1) {
+	groups = attrRegex.FindAllStringSubmatch(content, limit)
+	hit(len(groups))
+	for _, m := range groups {
 		if len(m) > 2 {
-			add(m[2])
+			set.add(m[2])
+		}
+	}
+	if set.addPatternsN(content, extra, limit) {
+		truncated = true
+	}
+	return set.found, truncated
+}
+
+// linkSet accumulates unique, well-formed URL candidates in discovery order.
+type linkSet struct {
+	seen      map[string]bool
+	found     []string
+	limit     int  // Candidates kept, unlimited when 0
+	truncated bool // Candidates were dropped because of limit
+}
+
+func (l *linkSet) add(s string) {
+	if l.seen == nil {
+		l.seen = make(map[string]bool)
+	}
+	if l.limit > 0 && len(l.found) >= l.limit {
+		l.truncated = true
+		return
+	}
+	if !l.seen[s] && len(s) > 1 && wellFormed(s) {
+		l.found = append(l.found, s)
+		l.seen[s] = true
+	}
+}
+
+// addPatterns applies user patterns, taking capture group 1 (or the whole
+// match when there is no group) as the URL. Each pattern stops after the
+// set's limit.
+func (l *linkSet) addPatterns(content string, patterns []*regexp.Regexp) {
+	limit := l.limit
+	if limit <= 0 {
+		limit = -1
+	}
+	if l.addPatternsN(content, patterns, limit) {
+		l.truncated = true
+	}
+}
+
+// addPatternsN is addPatterns with at most limit matches per pattern. It
+// reports whether a pattern hit the limit.
+func (l *linkSet) addPatternsN(content string, patterns []*regexp.Regexp, limit int) bool {
+	truncated := false
+	for _, re := range patterns {
+		matches := re.FindAllStringSubmatch(content, limit)
+		truncated = truncated || (limit >= 0 && len(matches) >= limit)
+		for _, m := range matches {
+			if len(m) > 1 {
+				l.add(m[1])
+			} else {
+				l.add(m[0])
+			}
+		}
+	}
+	return truncated
+}
+
+// wellFormed rejects candidates that cannot be a usable URL: whitespace or
+// control characters, stray quotes or brackets, broken percent-escapes, or
+// anything url.Parse refuses.
+func wellFormed(s string) bool {
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b <= ' ' || b == 0x7f || strings.IndexByte(`"'<>\`+"`", b) >= 0 {
+			return false
+		}
+		if b == '%' {
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return false
+			}
+		}
+	}
+	_, err := url.Parse(s)
+	return err == nil
+}
+
+func isHex(b byte) bool {
+	return ('0' <= b && b <= '9') || ('a' <= b && b <= 'f') || ('A' <= b && b <= 'F')
+}
+
+// compilePatterns compiles user-supplied regexes, reporting the first invalid one.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
 		}
+		compiled = append(compiled, re)
 	}
-	return found
+	return compiled, nil
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWellFormed(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"/about", true},
+		{"https://example.com/a%20b", true},
+		{"/search?q=a%2Fb", true},
+		{"/a%2", false},
+		{"/a%zz", false},
+		{"/100%", false},
+		{"/a b", false},
+		{"/a\tb", false},
+		{"/a\x00b", false},
+		{"/a\x7fb", false},
+		{`/a"b`, false},
+		{"/a'b", false},
+		{"/a<b>", false},
+		{`/a\b`, false},
+		{"/a`b", false},
+		{"http://[::1", false},
+	}
+	for _, tt := range tests {
+		if got := wellFormed(tt.in); got != tt.want {
+			t.Errorf("wellFormed(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExtractMalformed(t *testing.T) {
+	content := `<html><body>
+		<a href="/ok">ok</a>
+		<a href="/broken%zzescape">bad escape</a>
+		<a href="/trailing%">trailing percent</a>
+		<a href="/tab	inside">tab</a>
+		<img src="/img\x01.png">
+		<a href="http://[::1/unclosed">ipv6</a>
+		<script>var u = "/api/v1/users";</script>
+		<a href='/quote"mix'>mixed quotes</a>
+		https://example.com/full%2Gpath
+	</body></html>`
+
+	links := Extract(content)
+	for _, l := range links {
+		if !wellFormed(l) {
+			t.Errorf("Extract returned malformed %q", l)
+		}
+	}
+	for _, want := range []string{"/ok", "/api/v1/users"} {
+		if !slices.Contains(links, want) {
+			t.Errorf("Extract dropped %q, got %q", want, links)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ExtractorFunc returns the links found in a response body. Extra patterns
+// are the user's --pattern regexes and should be applied as Extract does.
+type ExtractorFunc func(content string, extra ...*regexp.Regexp) []string
+
+// defaultExtractors maps media types to the extractor used for them. HTML
+// is parsed unless parseMode is "regex". Unlisted types, JavaScript
+// included, fall back to the regex extractor. Each keeps at most limit
+// candidates per document, all when limit is 0.
+func defaultExtractors(parseMode string, limit int) map[string]ExtractorFunc {
+	if limit <= 0 {
+		limit = -1
+	}
+	capped := func(fn func(string, int, ...*regexp.Regexp) ([]string, bool)) ExtractorFunc {
+		return func(content string, extra ...*regexp.Regexp) []string {
+			links, _ := fn(content, limit, extra...)
+			return links
+		}
+	}
+	extractors := map[string]ExtractorFunc{
+		"application/json":     capped(extractJSON),
+		"application/xml":      capped(extractXML),
+		"text/xml":             capped(extractXML),
+		"application/rss+xml":  capped(extractFeed),
+		"application/atom+xml": capped(extractFeed),
+	}
+	if parseMode != "regex" {
+		extractors["text/html"] = capped(extractDOM)
+		extractors["application/xhtml+xml"] = capped(extractDOM)
+	}
+	return extractors
+}
+
+// RegisterExtractor sets the extractor used for responses of the given
+// media type (e.g. "application/ld+json"), replacing any default.
+func (c *Crawler) RegisterExtractor(mediaType string, fn ExtractorFunc) {
+	c.extractorsMu.Lock()
+	c.extractors[strings.ToLower(mediaType)] = fn
+	c.extractorsMu.Unlock()
+}
+
+// extractorFor picks the extractor for a Content-Type header. Structured
+// syntax suffixes such as "+json" or "+xml" use the extractor of their base
+// type when no specific one is registered. It returns nil when the regex
+// extractor applies.
+func (c *Crawler) extractorFor(contentType string) ExtractorFunc {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	c.extractorsMu.RLock()
+	defer c.extractorsMu.RUnlock()
+	if fn, ok := c.extractors[mediaType]; ok {
+		return fn
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		if fn, ok := c.extractors["application/"+mediaType[i+1:]]; ok {
+			return fn
+		}
+	}
+	return nil
+}
+
+// extract returns the links of a page body with the extractor registered
+// for its Content-Type, or the regex extractor bounded by MaxMatchesPerDoc.
+// Candidates shorter than MinURLLength are dropped, whatever the extractor.
+func (c *Crawler) extract(contentType, content string, page *url.URL) []string {
+	var links []string
+	if fn := c.extractorFor(contentType); fn != nil {
+		links = fn(content, c.patterns...)
+	} else {
+		limit := c.Config.MaxMatchesPerDoc
+		if limit <= 0 {
+			limit = -1
+		}
+		var truncated bool
+		links, truncated = ExtractN(content, limit, c.patterns...)
+		if truncated && c.Config.Verbose {
+			c.printf("[%s] %s: match limit (%d) reached, some links skipped\n", color.YellowString("WRN"), page, limit)
+		}
+	}
+	if c.Config.MinURLLength > 0 {
+		links = slices.DeleteFunc(links, func(l string) bool {
+			return len(l) < c.Config.MinURLLength
+		})
+	}
+	return links
+}
+
+// looksLikeLink keeps string values that are URLs or paths rather than text.
+func looksLikeLink(s string) bool {
+	for _, prefix := range []string{"http://", "https://", "ws://", "wss://", "//", "/", "./", "../"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractJSON walks a JSON document and returns the string values that look
+// like links. Documents that don't parse are scanned with Extract.
+func ExtractJSON(content string, extra ...*regexp.Regexp) []string {
+	links, _ := extractJSON(content, -1, extra...)
+	return links
+}
+
+// extractJSON is ExtractJSON keeping at most limit candidates, all when
+// limit is negative. truncated reports whether some were dropped.
+func extractJSON(content string, limit int, extra ...*regexp.Regexp) ([]string, bool) {
+	var doc any
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return ExtractN(content, limit, extra...)
+	}
+
+	links := linkSet{limit: limit}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			if s := strings.TrimSpace(v); looksLikeLink(s) {
+				links.add(s)
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		case map[string]any:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(doc)
+	links.addPatterns(content, extra)
+	return links.found, links.truncated
+}
+
+// ExtractXML returns the element texts and attribute values of an XML
+// document that look like links, such as sitemap <loc> entries. Documents
+// that don't parse are scanned with Extract.
+func ExtractXML(content string, extra ...*regexp.Regexp) []string {
+	links, _ := extractXML(content, -1, extra...)
+	return links
+}
+
+// extractXML is ExtractXML keeping at most limit candidates, see extractJSON.
+func extractXML(content string, limit int, extra ...*regexp.Regexp) ([]string, bool) {
+	links := linkSet{limit: limit}
+	dec := xml.NewDecoder(strings.NewReader(content))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if len(links.found) == 0 {
+				return ExtractN(content, limit, extra...)
+			}
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				if s := strings.TrimSpace(attr.Value); looksLikeLink(s) {
+					links.add(s)
+				}
+			}
+		case xml.CharData:
+			if s := strings.TrimSpace(string(t)); looksLikeLink(s) {
+				links.add(s)
+			}
+		}
+	}
+	links.addPatterns(content, extra)
+	return links.found, links.truncated
+}
+
+// ExtractFeed returns the entry URLs of an RSS or Atom feed: <link> text or
+// href, permalink <guid>s, <enclosure url> and sitemap-style <loc>.
+// Documents that don't parse are scanned with Extract.
+func ExtractFeed(content string, extra ...*regexp.Regexp) []string {
+	links, _ := extractFeed(content, -1, extra...)
+	return links
+}
+
+// extractFeed is ExtractFeed keeping at most limit candidates, see extractJSON.
+func extractFeed(content string, limit int, extra ...*regexp.Regexp) ([]string, bool) {
+	links := linkSet{limit: limit}
+	var inElem string
+	dec := xml.NewDecoder(strings.NewReader(content))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if len(links.found) == 0 {
+				return ExtractN(content, limit, extra...)
+			}
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			inElem = t.Name.Local
+			for _, attr := range t.Attr {
+				if (inElem == "link" && attr.Name.Local == "href") || (inElem == "enclosure" && attr.Name.Local == "url") {
+					links.add(strings.TrimSpace(attr.Value))
+				}
+			}
+		case xml.EndElement:
+			inElem = ""
+		case xml.CharData:
+			s := strings.TrimSpace(string(t))
+			switch inElem {
+			case "link", "loc":
+				links.add(s)
+			case "guid":
+				// Guids are opaque identifiers unless they are URLs
+				if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+					links.add(s)
+				}
+			}
+		}
+	}
+	links.addPatterns(content, extra)
+	return links.found, links.truncated
+}
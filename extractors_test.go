@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractMinURLLength(t *testing.T) {
+	page, _ := url.Parse("http://a.test/")
+	tests := []struct {
+		contentType, content string
+	}{
+		{"text/html", `<a href="/a">a</a> <a href="/long-path">b</a>`},
+		{"text/plain", `"/a" "/long-path"`},
+		{"application/json", `{"short": "/a", "long": "/long-path"}`},
+		{"application/xml", `<r><s>/a</s><l>/long-path</l></r>`},
+	}
+	c, err := New(Config{MinURLLength: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		links := c.extract(tt.contentType, tt.content, page)
+		if slices.Contains(links, "/a") || !slices.Contains(links, "/long-path") {
+			t.Errorf("%s: extract = %q, want only /long-path", tt.contentType, links)
+		}
+	}
+
+	c.addResult(linkInfo{url: "/a"}, "", 0)
+	c.addResult(linkInfo{url: "/long-path"}, "", 0)
+	if urls := resultURLs(c); !slices.Equal(urls, []string{"/long-path"}) {
+		t.Errorf("addResult kept %q, want only /long-path", urls)
+	}
+}
+
+func TestExtractMaxMatches(t *testing.T) {
+	var html, jsonDoc, xmlDoc, feed strings.Builder
+	jsonDoc.WriteString(`[`)
+	xmlDoc.WriteString(`<urlset>`)
+	feed.WriteString(`<rss><channel>`)
+	for i := range 50 {
+		fmt.Fprintf(&html, `<a href="/p%d">x</a> `, i)
+		if i > 0 {
+			jsonDoc.WriteString(`,`)
+		}
+		fmt.Fprintf(&jsonDoc, `"/p%d"`, i)
+		fmt.Fprintf(&xmlDoc, `<url><loc>/p%d</loc></url>`, i)
+		fmt.Fprintf(&feed, `<item><link>/p%d</link></item>`, i)
+	}
+	jsonDoc.WriteString(`]`)
+	xmlDoc.WriteString(`</urlset>`)
+	feed.WriteString(`</channel></rss>`)
+
+	extra := regexp.MustCompile(`/(p\d+)`)
+	// The regex extractor caps each regex, the others the whole document
+	tests := []struct {
+		contentType, content string
+		max                  int
+	}{
+		{"text/html", html.String(), 10},
+		{"text/plain", html.String(), 20},
+		{"application/json", jsonDoc.String(), 10},
+		{"application/xml", xmlDoc.String(), 10},
+		{"application/rss+xml", feed.String(), 10},
+	}
+	c, err := New(Config{MaxMatchesPerDoc: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.patterns = []*regexp.Regexp{extra}
+	page, _ := url.Parse("http://a.test/")
+	for _, tt := range tests {
+		if links := c.extract(tt.contentType, tt.content, page); len(links) > tt.max {
+			t.Errorf("%s: %d links, want at most %d", tt.contentType, len(links), tt.max)
+		}
+	}
+
+	var set linkSet
+	set.limit = 3
+	set.addPatterns(html.String(), []*regexp.Regexp{extra})
+	if len(set.found) != 3 || !set.truncated {
+		t.Errorf("addPatterns kept %d (truncated %v), want 3", len(set.found), set.truncated)
+	}
+}
+
+func TestExtractDOMInlineWebSocket(t *testing.T) {
+	content := `<html><head>
+		<script>const socket = new WebSocket("wss://example.com/live?room=1");</script>
+		<script src="/app.js"></script>
+	</head><body>
+		<p>Not a script: ws://example.com/text</p>
+		<a href="/about">about</a>
+	</body></html>`
+
+	links := ExtractDOM(content)
+	for _, want := range []string{"wss://example.com/live?room=1", "/app.js", "/about"} {
+		if !slices.Contains(links, want) {
+			t.Errorf("ExtractDOM dropped %q, got %q", want, links)
+		}
+	}
+	if slices.Contains(links, "ws://example.com/text") {
+		t.Errorf("ExtractDOM took a URL from text, got %q", links)
+	}
+}
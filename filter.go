@@ -0,0 +1,21 @@
+package main
+
+// urlAllowed reports whether u passes the include and exclude patterns: it
+// must match one of IncludePatterns, when there are any, and none of
+// ExcludePatterns.
+func (c *Crawler) urlAllowed(u string) bool {
+	for _, re := range c.excludes {
+		if re.MatchString(u) {
+			return false
+		}
+	}
+	if len(c.includes) == 0 {
+		return true
+	}
+	for _, re := range c.includes {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
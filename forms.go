@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/url"
+
+	"github.com/fatih/color"
+)
+
+// addForms resolves the forms found on page and records the ones not seen
+// yet, keyed by method and action.
+func (c *Crawler) addForms(forms []Form, page *url.URL) {
+	for _, f := range forms {
+		action, err := page.Parse(f.Action)
+		if err != nil {
+			continue
+		}
+		f.Action = normalizeURL(action)
+		f.FoundOn = page.String()
+
+		if _, loaded := c.seenForms.LoadOrStore(f.Method+" "+f.Action, true); loaded {
+			continue
+		}
+		c.printf("[%s] %s %s %v\n", color.YellowString("FRM"), f.Method, c.formatResult(f.Action), f.Fields)
+		c.resultsMu.Lock()
+		c.Forms = append(c.Forms, f)
+		c.resultsMu.Unlock()
+	}
+}
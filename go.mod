@@ -2,10 +2,20 @@ module github.com/ygp4ph/yg-scovery/v2
 
 go 1.25.5
 
-require github.com/fatih/color v1.18.0
+require (
+	github.com/Azure/go-ntlmssp v0.1.1
+	github.com/andybalholm/cascadia v1.3.5
+	github.com/fatih/color v1.18.0
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+	golang.org/x/net v0.55.0
+	golang.org/x/time v0.15.0
+)
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.25.0 // indirect
+	golang.org/x/sys v0.45.0 // indirect
+	golang.org/x/text v0.37.0 // indirect
 )
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// HashRouteMode values. Fragments are kept by default, so URLs differing
+// only by their fragment are distinct.
+const (
+	HashRouteKeep     = "keep"
+	HashRouteCollapse = "collapse" // Drop fragments, the document is crawled once
+	HashRouteRoutes   = "routes"   // Collapse, and record "#/..." routes as findings
+)
+
+// isHashRoute reports whether a fragment looks like a client-side route
+// ("#/users/1" or "#!/users/1") rather than an in-page anchor.
+func isHashRoute(fragment string) bool {
+	return strings.HasPrefix(fragment, "/") || strings.HasPrefix(fragment, "!/")
+}
+
+// applyHashRouteMode strips the fragment of u according to HashRouteMode,
+// recording it first as a route in routes mode.
+func (c *Crawler) applyHashRouteMode(u *url.URL, foundOn string) *url.URL {
+	mode := c.Config.HashRouteMode
+	if (mode != HashRouteCollapse && mode != HashRouteRoutes) || (u.Fragment == "" && u.RawFragment == "") {
+		return u
+	}
+	if mode == HashRouteRoutes && isHashRoute(u.Fragment) {
+		c.addHashRoute(normalizeURL(u), foundOn)
+	}
+	n := *u
+	n.Fragment, n.RawFragment = "", ""
+	return &n
+}
+
+// addHashRoute records a hash-route the first time it is seen.
+func (c *Crawler) addHashRoute(u, foundOn string) {
+	if _, loaded := c.Visited.LoadOrStore(u, true); loaded {
+		return
+	}
+	c.printf("[%s] %s\n", color.BlueString("RTE"), c.formatResult(u))
+	c.resultsMu.Lock()
+	c.HashRoutes = append(c.HashRoutes, Result{
+		URL:          u,
+		FoundOn:      foundOn,
+		DiscoveredAt: time.Now(),
+	})
+	c.resultsMu.Unlock()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/url"
+
+	"github.com/fatih/color"
+)
+
+// addSubresources records the scripts and stylesheets of a page with their
+// integrity hashes, once per URL and hash.
+func (c *Crawler) addSubresources(resources []Subresource, page *url.URL) {
+	for _, sr := range resources {
+		abs, err := page.Parse(sr.URL)
+		if err != nil {
+			continue
+		}
+		sr.URL = normalizeURL(abs)
+		sr.FoundOn = page.String()
+		if _, loaded := c.seenSRI.LoadOrStore(sr.URL+" "+sr.Integrity, true); loaded {
+			continue
+		}
+		integrity := sr.Integrity
+		if integrity == "" {
+			integrity = color.YellowString("no integrity")
+		}
+		c.printf("[%s] %s %s\n", color.BlueString("SRI"), c.formatResult(sr.URL), integrity)
+		c.resultsMu.Lock()
+		c.Subresources = append(c.Subresources, sr)
+		c.resultsMu.Unlock()
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// defaultKeywordWindow is the number of bytes kept on each side of a
+// keyword occurrence when KeywordWindow is unset.
+const defaultKeywordWindow = 512
+
+// maxWindowStretch bounds how far a window edge moves to reach a token
+// boundary, so a single huge token can't pull in the whole document.
+const maxWindowStretch = 2048
+
+// keywordWindows keeps only the bytes of content within window bytes of a
+// case-insensitive occurrence of keyword. Each window is widened to the
+// surrounding whitespace or tag boundary so URLs crossing its edge stay
+// whole; overlapping windows are merged. It returns "" when keyword doesn't
+// occur.
+func keywordWindows(content, keyword string, window int) string {
+	if keyword == "" {
+		return content
+	}
+	if window <= 0 {
+		window = defaultKeywordWindow
+	}
+	lower := strings.ToLower(content)
+	kw := strings.ToLower(keyword)
+
+	var out bytes.Buffer
+	end := -1 // End of the last window written
+	for from := 0; ; {
+		i := strings.Index(lower[from:], kw)
+		if i < 0 {
+			break
+		}
+		i += from
+		from = i + len(kw)
+
+		lo := widen(content, max(0, i-window), -1)
+		hi := widen(content, min(len(content), from+window), 1)
+		if lo <= end {
+			lo = end
+		} else if end >= 0 {
+			out.WriteByte('\n')
+		}
+		if hi > lo {
+			out.WriteString(content[lo:hi])
+			end = hi
+		}
+	}
+	return out.String()
+}
+
+// widen moves pos in direction dir (-1 or 1) until it sits on whitespace,
+// a tag delimiter or the edge of s.
+func widen(s string, pos, dir int) int {
+	for n := 0; n < maxWindowStretch; n++ {
+		if pos <= 0 || pos >= len(s) {
+			return max(0, min(pos, len(s)))
+		}
+		if strings.IndexByte(" \t\r\n<>", s[pos]) >= 0 {
+			return pos
+		}
+		pos += dir
+	}
+	return pos
+}
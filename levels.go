@@ -0,0 +1,58 @@
+package main
+
+// tracksLevels reports whether anything consumes per-depth completion.
+func (c *Crawler) tracksLevels() bool {
+	return c.Config.OnLevelComplete != nil || c.Config.StreamPath != ""
+}
+
+// levelStart registers a page scheduled for crawling at the given depth.
+func (c *Crawler) levelStart(depth int) {
+	if !c.tracksLevels() {
+		return
+	}
+	c.levelMu.Lock()
+	c.levelPending[depth]++
+	c.levelMu.Unlock()
+}
+
+// levelDone marks a page at the given depth as crawled. Once no page is left
+// at a depth nor at any shallower one, nothing more can be discovered there
+// and the results found at that depth are streamed as a wave and passed to
+// OnLevelComplete.
+func (c *Crawler) levelDone(depth int) {
+	if !c.tracksLevels() {
+		return
+	}
+	c.levelMu.Lock()
+	c.levelPending[depth]--
+	var completed []int
+	for c.nextLevel < c.Config.MaxDepth && c.levelPending[c.nextLevel] == 0 {
+		completed = append(completed, c.nextLevel)
+		c.nextLevel++
+	}
+	// Streamed under levelMu so that waves are written in depth order
+	waves := make([][]Result, len(completed))
+	for i, level := range completed {
+		waves[i] = c.levelResults(level)
+		c.streamWave(level, waves[i])
+	}
+	c.levelMu.Unlock()
+
+	if c.Config.OnLevelComplete != nil {
+		for i, level := range completed {
+			c.Config.OnLevelComplete(level, waves[i])
+		}
+	}
+}
+
+func (c *Crawler) levelResults(depth int) []Result {
+	var results []Result
+	c.resultsMu.Lock()
+	for _, r := range c.Results {
+		if r.Depth == depth {
+			results = append(results, r)
+		}
+	}
+	c.resultsMu.Unlock()
+	return results
+}
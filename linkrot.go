@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// BrokenLink is an internal link that failed validation, with every page
+// linking to it.
+type BrokenLink struct {
+	URL        string   `json:"url"`
+	Status     int      `json:"status,omitempty"` // 0 when no response was received
+	LinkedFrom []string `json:"linked_from"`
+}
+
+// addBrokenLink records that foundOn links to the broken internal URL u.
+// Links are validated once but checked for every page they appear on, so
+// all referrers are collected.
+func (c *Crawler) addBrokenLink(u string, status int, foundOn string) {
+	if !c.Config.LinkRot {
+		return
+	}
+	c.resultsMu.Lock()
+	defer c.resultsMu.Unlock()
+	if i, ok := c.brokenIndex[u]; ok {
+		if !slices.Contains(c.Broken[i].LinkedFrom, foundOn) {
+			c.Broken[i].LinkedFrom = append(c.Broken[i].LinkedFrom, foundOn)
+		}
+		return
+	}
+	if c.brokenIndex == nil {
+		c.brokenIndex = make(map[string]int)
+	}
+	c.brokenIndex[u] = len(c.Broken)
+	c.Broken = append(c.Broken, BrokenLink{URL: u, Status: status, LinkedFrom: []string{foundOn}})
+	if c.Config.Verbose {
+		c.printf("[%s] %s (%d)\n", color.RedString("BRK"), c.formatResult(u), status)
+	}
+}
+
+// BrokenLinks returns the link-rot report sorted by URL, referrers sorted.
+func (c *Crawler) BrokenLinks() []BrokenLink {
+	c.resultsMu.Lock()
+	out := make([]BrokenLink, len(c.Broken))
+	for i, b := range c.Broken {
+		b.LinkedFrom = slices.Clone(b.LinkedFrom)
+		sort.Strings(b.LinkedFrom)
+		out[i] = b
+	}
+	c.resultsMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
@@ -1,59 +1,361 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
 )
 
 var Version = "v2.2.0"
 
+// multiFlag collects the values of a flag that may be repeated.
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
+
 func main() {
 	var (
 		u                          string
 		d                          int
 		onlyExternal, onlyInternal bool
 		output                     string
+		outputDir                  string
 		h, verbose, showVersion    bool
 		tree                       bool
+		outputStyle                string
+		deterministic              bool
+		rampUp                     time.Duration
+		dirStats                   bool
+		probeSensitive             bool
+		sensitiveFiles             string
+		tracePath                  string
+		patterns                   multiFlag
+		maxResponseTime            time.Duration
+		maxPages                   int
+		minContentLength           int
+		authScheme, authUser       string
+		authPassword               string
+		sigv4Region, awsKey        string
+		awsSecret                  string
+		cookies                    multiFlag
+		headers                    multiFlag
+		userAgent                  string
+		proxyURL                   string
+		templates                  multiFlag
+		templateValues             multiFlag
+		levels                     bool
+		blockPrivate               bool
+		within                     multiFlag
+		keyword                    string
+		keywordWindow              int
+		pagination                 bool
+		nextPatterns               multiFlag
+		includes, excludes         multiFlag
+		scopeMode                  string
+		nextSelectors              multiFlag
+		parseMode                  string
+		render                     bool
+		renderEndpoint             string
+		forms                      bool
+		titles                     bool
+		redirects                  bool
+		scheme                     string
+		maxReads                   int
+		canonical                  bool
+		crawlWorkers               int
+		validationWorkers          int
+		idleTimeout                time.Duration
+		seedFile                   string
+		requireContent             string
+		honorCanonical             bool
+		sitemap                    string
+		useSitemap                 bool
+		discrepancies              bool
+		linkRot                    bool
+		crawlDelay                 bool
+		respectRobots              bool
+		skipProtected              bool
+		estimate                   bool
+		benchmark                  bool
+		printSummary               bool
+		externalTLDs               string
+		maxRuntimePerHost          time.Duration
+		traceRedirects             int
+		traceSecrets               bool
+		mixedContent               bool
+		extractIntegrity           bool
+		openSearch                 bool
+		bandwidthLimit             int64
+		requestDelay               time.Duration
+		requestsPerSecond          float64
+		adaptiveConcurrency        bool
+		breakerThreshold           int
+		breakerCooldown            time.Duration
+		webhookURL                 string
+		sampleRate                 float64
+		queryAsChild               bool
+		minURLLength               int
+		maxPathDepth               int
+		hashRouteMode              string
+		maxMatches                 int
+		streamPath                 string
+		statePath                  string
+		autoSave                   time.Duration
+		crawlWindow                string
+		stripSessions              bool
+		sessionPatterns            multiFlag
+		validationCache            string
+		validationCacheTTL         time.Duration
+		outputBuffer               int
+		flushInterval              time.Duration
 	)
 
 	flag.StringVar(&u, "u", "", "Target URL")
 	flag.StringVar(&u, "url", "", "Target URL")
+	flag.StringVar(&scheme, "scheme", "https", "Scheme for targets without one (http, https)")
+	flag.StringVar(&seedFile, "seeds", "", "JSON file of extra seed requests (method, body)")
+	flag.Var(&templates, "template", "URL template such as /users/{id} to expand and validate (repeatable)")
+	flag.Var(&templateValues, "values", "Values for a template placeholder, as name=v1,v2 or name=@file (repeatable)")
 	flag.IntVar(&d, "d", 3, "Max recursion depth")
 	flag.IntVar(&d, "depth", 3, "Max recursion depth")
+	flag.IntVar(&maxPages, "max-pages", 0, "Stop after fetching this many pages (0 = unlimited)")
 	flag.BoolVar(&onlyExternal, "e", false, "External links only")
 	flag.BoolVar(&onlyExternal, "ext", false, "External links only")
 	flag.BoolVar(&onlyInternal, "i", false, "Internal links only")
 	flag.BoolVar(&onlyInternal, "int", false, "Internal links only")
+	flag.BoolVar(&blockPrivate, "block-private", false, "Refuse to crawl private/loopback addresses")
+	flag.StringVar(&authScheme, "auth", "", "Authentication scheme for the target (basic, ntlm)")
+	flag.StringVar(&authUser, "auth-user", "", "Authentication user (DOMAIN\\user for NTLM)")
+	flag.StringVar(&authPassword, "auth-pass", "", "Authentication password")
+	flag.Var(&cookies, "cookie", "Cookies as [regex::]name=value; name=value, sent to matching URLs (repeatable)")
+	flag.Var(&headers, "H", "Extra request header as \"Name: value\" (repeatable)")
+	flag.Var(&headers, "header", "Extra request header as \"Name: value\" (repeatable)")
+	flag.StringVar(&userAgent, "user-agent", "", "User-Agent sent with every request (default Yg-scovery/<version>)")
+	flag.StringVar(&proxyURL, "proxy", "", "Proxy URL for every request (http://, https://, socks5://)")
+	flag.StringVar(&sigv4Region, "sigv4", "", "Sign requests with AWS SigV4 for region[:service]")
+	flag.StringVar(&awsKey, "aws-key", "", "AWS access key ID (default $AWS_ACCESS_KEY_ID)")
+	flag.StringVar(&awsSecret, "aws-secret", "", "AWS secret access key (default $AWS_SECRET_ACCESS_KEY)")
 	flag.StringVar(&output, "o", "", "Output file (JSON)")
 	flag.StringVar(&output, "output", "", "Output file (JSON)")
+	flag.StringVar(&outputDir, "output-dir", "", "Directory for one JSON file per category (internal, external, emails, forms)")
+	flag.BoolVar(&canonical, "canonical", false, "Sorted, diffable JSON output without timestamps")
+	flag.StringVar(&tracePath, "trace", "", "Trace file (HAR)")
+	flag.StringVar(&outputStyle, "output-style", "absolute", "Result style (absolute, relative)")
+	flag.BoolVar(&forms, "forms", false, "Extract forms and their fields")
+	flag.BoolVar(&titles, "titles", false, "Validate internal links with GET and record page titles")
+	flag.BoolVar(&redirects, "redirects", false, "Flag links that resolve through a 3xx redirect, with its Location")
+	flag.StringVar(&parseMode, "parse", "dom", "HTML extraction mode (dom, regex)")
+	flag.BoolVar(&render, "render", false, "Extract links from pages rendered by a headless Chrome")
+	flag.StringVar(&renderEndpoint, "render-endpoint", "", "DevTools endpoint of a running browser, e.g. http://127.0.0.1:9222")
+	flag.IntVar(&outputBuffer, "buffer", 0, "Buffer this many bytes of console output (0 = unbuffered)")
+	flag.DurationVar(&flushInterval, "flush-interval", 0, "Max delay before buffered output is written (default 1s)")
+	flag.StringVar(&validationCache, "validation-cache", "", "Reuse link validations from this file across runs")
+	flag.DurationVar(&validationCacheTTL, "validation-cache-ttl", 0, "Re-check cached validations older than this (default 24h)")
+	flag.BoolVar(&stripSessions, "strip-sessions", false, "Strip common session IDs (jsessionid, PHPSESSID, ASP.NET) from URLs")
+	flag.Var(&sessionPatterns, "session-pattern", "Extra session ID regex stripped from URLs (repeatable)")
+	flag.StringVar(&crawlWindow, "window", "", "Only crawl during this local time of day (e.g. 22:00-06:00)")
+	flag.StringVar(&streamPath, "jsonl", "", "Stream results as JSON Lines, one wave per depth (- for stdout)")
+	flag.StringVar(&statePath, "state", "", "Checkpoint file for the crawl state (visited, frontier, results)")
+	flag.DurationVar(&autoSave, "autosave", 0, "Write the --state checkpoint at this interval (e.g. 1m)")
+	flag.StringVar(&hashRouteMode, "hash-routes", HashRouteKeep, "URLs differing only by fragment: keep, collapse, routes")
+	flag.StringVar(&scopeMode, "scope", ScopeHost, "Internal links: host (target host only) or domain/subdomains (with subdomains)")
+	flag.IntVar(&maxPathDepth, "max-path-depth", 0, "Ignore links with more path segments than this (0 = unlimited)")
+	flag.Var(&includes, "include", "Only keep URLs matching this regex (repeatable)")
+	flag.Var(&excludes, "exclude", "Skip URLs matching this regex, without probing them (repeatable)")
+	flag.IntVar(&maxMatches, "max-matches", 0, "Max matches per extractor or extraction regex on a page (0 = unlimited)")
+	flag.IntVar(&minURLLength, "min-length", 0, "Ignore extracted links shorter than this many characters")
+	flag.BoolVar(&queryAsChild, "query-nodes", false, "Show query strings as child nodes in the tree")
+	flag.Float64Var(&sampleRate, "sample", 0, "Only recurse into this fraction (0-1) of internal pages")
+	flag.StringVar(&webhookURL, "webhook", "", "POST each new result as JSON to this URL")
+	flag.IntVar(&breakerThreshold, "breaker", 0, "Pause a host after this many consecutive failures (0 = never)")
+	flag.DurationVar(&breakerCooldown, "breaker-cooldown", defaultBreakerCooldown, "Pause before a tripped host is probed again")
+	flag.BoolVar(&adaptiveConcurrency, "adaptive", false, "Start with few concurrent requests and adapt to the error rate")
+	flag.Int64Var(&bandwidthLimit, "bandwidth", 0, "Max bytes per second read from page bodies (0 = unlimited)")
+	flag.DurationVar(&requestDelay, "delay", 0, "Minimum delay between requests to the same host (e.g. 500ms)")
+	flag.Float64Var(&requestsPerSecond, "rps", 0, "Max requests per second to each host (0 = unlimited)")
+	flag.BoolVar(&openSearch, "opensearch", false, "Record search URL templates from OpenSearch descriptions")
+	flag.BoolVar(&extractIntegrity, "sri", false, "Report scripts and stylesheets with their integrity hashes")
+	flag.BoolVar(&mixedContent, "mixed-content", false, "Report http:// resources loaded by HTTPS pages")
+	flag.IntVar(&traceRedirects, "trace-redirects", 0, "Redirect hops recorded per request in the trace (0 = all)")
+	flag.BoolVar(&traceSecrets, "trace-secrets", false, "Keep credential and custom headers in the trace instead of redacting them")
+	flag.DurationVar(&maxRuntimePerHost, "host-timeout", 0, "Stop crawling a host this long after its first page")
+	flag.StringVar(&externalTLDs, "tlds", "", "Only report external links under these comma-separated TLDs or domains")
+	flag.BoolVar(&printSummary, "summary", false, "Print a JSON summary on stderr and exit 0 (success), 3 (partial) or 1 (failed)")
+	flag.BoolVar(&benchmark, "bench", false, "Report pages/s, bytes/s, goroutines and memory during the crawl")
+	flag.BoolVar(&estimate, "estimate", false, "Only fetch the target and project how wide the crawl would be")
+	flag.BoolVar(&skipProtected, "skip-waf", false, "Stop crawling hosts that serve bot-protection challenges")
+	flag.BoolVar(&crawlDelay, "crawl-delay", false, "Honor the Crawl-delay of each host's robots.txt")
+	flag.BoolVar(&respectRobots, "robots", false, "Skip URLs disallowed by robots.txt and honor its Crawl-delay")
+	flag.BoolVar(&discrepancies, "discrepancies", false, "Export visited URLs missing from the results, with the reason")
+	flag.BoolVar(&linkRot, "link-rot", false, "Export broken internal links with the pages linking to them")
+	flag.StringVar(&sitemap, "sitemap", "", "Report coverage of this sitemap URL or file")
+	flag.BoolVar(&useSitemap, "seed-sitemap", false, "Crawl the URLs of /sitemap.xml (or --sitemap) as seeds")
+	flag.BoolVar(&honorCanonical, "honor-canonical", false, "Collapse pages onto their rel=canonical URL")
+	flag.StringVar(&requireContent, "require", "", "Only recurse into pages matching this regex or substring")
+	flag.BoolVar(&pagination, "pagination", false, "Follow next-page links (rel=next, \"Next »\") without consuming depth")
+	flag.Var(&nextPatterns, "next-pattern", "Extra regex matching the text of next-page links (repeatable)")
+	flag.Var(&nextSelectors, "next-selector", "CSS selector of next-page links (repeatable)")
+	flag.StringVar(&keyword, "near", "", "Only extract links near this keyword (case-insensitive)")
+	flag.IntVar(&keywordWindow, "near-window", defaultKeywordWindow, "Bytes around each --near keyword links are taken from")
+	flag.Var(&within, "within", "Only extract links inside elements matching this CSS selector (repeatable)")
+	flag.Var(&patterns, "pattern", "Extra extraction regex, group 1 is the URL (repeatable)")
+	flag.BoolVar(&deterministic, "deterministic", false, "Process discovered links in sorted order")
+	flag.IntVar(&crawlWorkers, "crawl-workers", 0, "Concurrent page fetches (0 = auto)")
+	flag.IntVar(&validationWorkers, "validation-workers", 0, "Concurrent link validations (0 = auto)")
+	flag.IntVar(&maxReads, "max-reads", 0, "Max response bodies read and parsed at once (0 = unbounded)")
+	flag.DurationVar(&idleTimeout, "idle-timeout", 0, "Stop when no new result appears for this long")
+	flag.DurationVar(&maxResponseTime, "max-response-time", 0, "Don't recurse into pages slower than this")
+	flag.IntVar(&minContentLength, "min-content", 0, "Don't recurse into pages whose body is shorter than this many bytes")
+	flag.DurationVar(&rampUp, "ramp-up", 0, "Ramp concurrency up to the maximum over this duration")
+	flag.BoolVar(&levels, "levels", false, "Report when each depth level is complete")
 	flag.BoolVar(&tree, "t", false, "Show internal links tree")
 	flag.BoolVar(&tree, "tree", false, "Show internal links tree")
+	flag.BoolVar(&dirStats, "dir-stats", false, "Show per-directory summary")
+	flag.BoolVar(&probeSensitive, "probe-sensitive", false, "Probe directories for sensitive files")
+	flag.StringVar(&sensitiveFiles, "sensitive-files", "", "Comma-separated sensitive files to probe")
 	flag.BoolVar(&h, "h", false, "Show help")
 	flag.BoolVar(&h, "help", false, "Show help")
 	flag.BoolVar(&verbose, "v", false, "Show errors")
 	flag.BoolVar(&verbose, "verbose", false, "Show errors")
 	flag.BoolVar(&showVersion, "version", false, "Show version")
 
+	// Console output, moved to stderr when the JSON Lines stream takes stdout
+	var console io.Writer = color.Output
 	banner := func() {
-		color.Cyan(`
+		fmt.Fprintln(console, color.CyanString(`
    __  ______ _      ______________ _   _____  _______  __
   / / / / __ `+"`"+`/_____/ ___/ ___/ __ \ | / / _ \/ ___/ / / /
  / /_/ / /_/ /_____(__  ) /__/ /_/ / |/ /  __/ /  / /_/ / 
  \__, /\__, /     /____/\___/\____/|___/\___/_/   \__, /  
 /____//____/                                     /____/   %s
- `, Version)
+ `, Version))
 	}
 
 	flag.Usage = func() {
 		banner()
-		fmt.Fprintf(os.Stderr, "\nUSAGE: %s [flags]\n\nFLAGS:\n  -u, --url\tTarget URL\n  -d, --depth\tMax recursion (default 3)\n  -e, --ext\tExternal links only\n  -i, --int\tInternal links only\n  -t, --tree\tShow internal links tree\n  -o, --output\tOutput file (JSON)\n  -v, --verbose\tShow errors\n  --version\tShow version\n  -h, --help\tShow help\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, `
+USAGE: %s [flags]
+
+FLAGS:
+  -u, --url		Target URL, or file:///path for an offline dump
+  --scheme		Scheme for targets without one (default https, falls back to http)
+  --seeds		JSON file of extra seed requests (url, method, body)
+  --template		URL template such as /users/{id} to expand and validate (repeatable)
+  --values		Values for a template placeholder, as name=v1,v2 or name=@file (repeatable)
+  -d, --depth		Max recursion (default 3)
+  --max-pages		Stop after fetching this many pages (0 = unlimited)
+  -e, --ext		External links only
+  -i, --int		Internal links only
+  --levels		Report when each depth level is complete
+  -t, --tree		Show internal links tree
+  --dir-stats		Show per-directory summary
+  --probe-sensitive	Probe directories for sensitive files (.git/config, .env...)
+  --sensitive-files	Comma-separated files to probe instead of the defaults
+  --block-private	Refuse to crawl private/loopback addresses
+  --auth		Authentication scheme for the target: basic, ntlm (no Kerberos)
+  --auth-user		Authentication user (DOMAIN\user for NTLM)
+  --auth-pass		Authentication password
+  --cookie		Cookies as [regex::]name=value; name=value, sent to matching URLs (repeatable)
+  -H, --header		Extra request header as "Name: value" (repeatable)
+  --user-agent		User-Agent sent with every request (default Yg-scovery/<version>)
+  --proxy		Proxy URL for every request (http://, https://, socks5://)
+  --sigv4		Sign requests with AWS SigV4 for region[:service] (service defaults to execute-api)
+  --aws-key		AWS access key ID (default $AWS_ACCESS_KEY_ID)
+  --aws-secret		AWS secret access key (default $AWS_SECRET_ACCESS_KEY)
+  -o, --output		Output file (JSON)
+  --output-dir		Directory for one JSON file per category (internal, external, emails, forms)
+  --canonical		Sorted, diffable JSON output without timestamps
+  --trace		Trace file of every request (HAR)
+  --output-style	Result style: absolute, relative (default absolute)
+  --forms		Extract forms and their fields
+  --titles		Validate internal links with GET and record page titles
+  --redirects		Flag links that resolve through a 3xx redirect, with its Location
+  --parse		HTML extraction mode: dom, regex (default dom)
+  --render		Extract links from pages rendered by a headless Chrome (slow)
+  --render-endpoint	DevTools endpoint of a running browser, e.g. http://127.0.0.1:9222
+  --buffer		Buffer this many bytes of console output (0 = unbuffered)
+  --flush-interval	Max delay before buffered output is written (default 1s)
+  --validation-cache	Reuse link validations from this file across runs
+  --validation-cache-ttl	Re-check cached validations older than this (default 24h)
+  --strip-sessions	Strip common session IDs (jsessionid, PHPSESSID, ASP.NET) from URLs
+  --session-pattern	Extra session ID regex stripped from URLs (repeatable)
+  --window		Only crawl during this local time of day (e.g. 22:00-06:00)
+  --jsonl		Stream results as JSON Lines, one wave per depth (- for stdout, console on stderr)
+  --state		Checkpoint file for the crawl state (visited, frontier, results)
+  --autosave		Write the --state checkpoint at this interval (e.g. 1m)
+  --hash-routes		URLs differing only by fragment: keep, collapse, routes (default keep)
+  --scope		Internal links: host (target host only) or domain/subdomains (with subdomains) (default host)
+  --max-path-depth	Ignore links with more path segments than this (0 = unlimited)
+  --include		Only keep URLs matching this regex (repeatable)
+  --exclude		Skip URLs matching this regex, without probing them (repeatable)
+  --max-matches		Max matches per extractor or extraction regex on a page (0 = unlimited)
+  --min-length		Ignore extracted links shorter than this many characters
+  --query-nodes		Show query strings as child nodes in the tree
+  --sample		Only recurse into this fraction (0-1) of internal pages
+  --webhook		POST each new result as JSON to this URL
+  --breaker		Pause a host after this many consecutive failures (0 = never)
+  --breaker-cooldown	Pause before a tripped host is probed again (default 30s)
+  --adaptive		Start with few concurrent requests and adapt to the error rate
+  --bandwidth		Max bytes per second read from page bodies (0 = unlimited)
+  --delay		Minimum delay between requests to the same host (e.g. 500ms)
+  --rps			Max requests per second to each host (0 = unlimited)
+  --opensearch		Record search URL templates from OpenSearch descriptions
+  --sri			Report scripts and stylesheets with their integrity hashes
+  --mixed-content	Report http:// resources loaded by HTTPS pages
+  --trace-redirects	Redirect hops recorded per request in the trace (0 = all)
+  --trace-secrets	Keep credential and custom headers in the trace instead of redacting them
+  --host-timeout	Stop crawling a host this long after its first page (e.g. 5m)
+  --tlds		Only report external links under these TLDs or domains (e.g. cn,ru)
+  --summary		Print a JSON summary on stderr and exit 0 (success), 3 (partial) or 1 (failed)
+  --bench		Report pages/s, bytes/s, goroutines and memory during the crawl
+  --estimate		Only fetch the target and project how wide the crawl would be
+  --skip-waf		Stop crawling hosts that serve bot-protection challenges
+  --crawl-delay		Honor the Crawl-delay of each host's robots.txt
+  --robots		Skip URLs disallowed by robots.txt and honor its Crawl-delay
+  --discrepancies	Export visited URLs missing from the results, with the reason
+  --link-rot		Export broken internal links with the pages linking to them
+  --sitemap		Report coverage of this sitemap URL or file
+  --seed-sitemap	Crawl the URLs of /sitemap.xml (or --sitemap) as seeds
+  --honor-canonical	Collapse pages onto their rel=canonical URL
+  --require		Only recurse into pages matching this regex or substring
+  --pagination		Follow next-page links (rel=next, "Next »") without consuming depth
+  --next-pattern	Extra regex matching the text of next-page links (repeatable)
+  --next-selector	CSS selector of next-page links (repeatable)
+  --near		Only extract links near this keyword (case-insensitive)
+  --near-window		Bytes around each --near keyword links are taken from (default 512)
+  --within		Only extract links inside this CSS selector, e.g. nav (repeatable)
+  --pattern		Extra extraction regex, group 1 is the URL (repeatable)
+  --deterministic	Process discovered links in sorted order
+  --crawl-workers	Concurrent page fetches (0 = auto)
+  --validation-workers	Concurrent link validations (0 = auto)
+  --max-reads		Max response bodies read and parsed at once (0 = unbounded)
+  --idle-timeout	Stop when no new result appears for this long (e.g. 2m)
+  --max-response-time	Don't recurse into pages slower than this (e.g. 5s)
+  --min-content		Don't recurse into pages whose body is shorter than this many bytes
+  --ramp-up		Ramp concurrency up over a duration (e.g. 30s)
+  -v, --verbose		Show errors
+  --version		Show version
+  -h, --help		Show help
+`, os.Args[0])
 	}
 	flag.Parse()
 
@@ -67,62 +369,373 @@ func main() {
 		os.Exit(0)
 	}
 
+	if streamPath == "-" {
+		console = color.Error
+	}
+
 	banner()
 	if u == "" {
-		color.Red("[ERR] -u <url> required")
-		fmt.Println("Use -h for help")
+		fmt.Fprintln(console, color.RedString("[ERR] -u <url> required"))
+		fmt.Fprintln(console, "Use -h for help")
+		os.Exit(1)
+	}
+	if scheme != "http" && scheme != "https" {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid scheme: %s (http, https)", scheme))
 		os.Exit(1)
 	}
-	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
-		u = "https://" + u
+	check := u
+	if !hasScheme(check) {
+		check = scheme + "://" + check
 	}
-	if _, err := url.Parse(u); err != nil {
-		color.Red("[ERR] Invalid URL: %v", err)
+	if _, err := url.Parse(check); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid URL: %v", err))
 		os.Exit(1)
 	}
 	if onlyExternal && onlyInternal {
-		color.Red("[ERR] Conflict: -e and -i")
+		fmt.Fprintln(console, color.RedString("[ERR] Conflict: -e and -i"))
+		os.Exit(1)
+	}
+	if (render || renderEndpoint != "") && blockPrivate {
+		fmt.Fprintln(console, color.RedString("[ERR] Conflict: --render and --block-private (the browser's requests aren't filtered)"))
+		os.Exit(1)
+	}
+	if authScheme != "" && authScheme != "basic" && authScheme != "ntlm" {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid auth scheme: %s (basic, ntlm)", authScheme))
+		os.Exit(1)
+	}
+	var signer func(*http.Request) error
+	if sigv4Region != "" {
+		region, service, _ := strings.Cut(sigv4Region, ":")
+		if awsKey == "" {
+			awsKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		}
+		if awsSecret == "" {
+			awsSecret = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		}
+		if awsKey == "" || awsSecret == "" {
+			fmt.Fprintln(console, color.RedString("[ERR] --sigv4 requires AWS credentials (--aws-key/--aws-secret)"))
+			os.Exit(1)
+		}
+		signer = SigV4{
+			AccessKey:    awsKey,
+			SecretKey:    awsSecret,
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			Region:       region,
+			Service:      service,
+		}.Sign
+	}
+	var cookieRules []CookieRule
+	for _, s := range cookies {
+		rule, err := ParseCookieRule(s)
+		if err != nil {
+			fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+			os.Exit(1)
+		}
+		cookieRules = append(cookieRules, rule)
+	}
+	if _, err := compileCookieRules(cookieRules); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if _, err := compilePatterns(sessionPatterns); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if _, err := compilePatterns(patterns); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if _, err := compilePatterns(nextPatterns); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if _, err := compilePatterns(includes); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if _, err := compilePatterns(excludes); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if _, err := compileSelectors(nextSelectors); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if _, err := compileSelectors(within); err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	if hashRouteMode != HashRouteKeep && hashRouteMode != HashRouteCollapse && hashRouteMode != HashRouteRoutes {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid hash route mode: %s (keep, collapse, routes)", hashRouteMode))
+		os.Exit(1)
+	}
+	if scopeMode != ScopeHost && scopeMode != ScopeDomain && scopeMode != ScopeSubdomains {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid scope: %s (host, domain, subdomains)", scopeMode))
+		os.Exit(1)
+	}
+	if parseMode != "regex" && parseMode != "dom" {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid parse mode: %s (dom, regex)", parseMode))
+		os.Exit(1)
+	}
+	var window TimeWindow
+	if crawlWindow != "" {
+		var err error
+		if window, err = ParseTimeWindow(crawlWindow); err != nil {
+			fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+			os.Exit(1)
+		}
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid sample rate: %v (0-1)", sampleRate))
+		os.Exit(1)
+	}
+	if proxyURL != "" {
+		if _, err := ParseProxyURL(proxyURL); err != nil {
+			fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+			os.Exit(1)
+		}
+	}
+	if requestDelay < 0 || requestsPerSecond < 0 {
+		fmt.Fprintln(console, color.RedString("[ERR] --delay and --rps must be positive"))
+		os.Exit(1)
+	}
+	if autoSave > 0 && statePath == "" {
+		fmt.Fprintln(console, color.RedString("[ERR] --autosave requires --state <file>"))
+		os.Exit(1)
+	}
+	if outputStyle != "absolute" && outputStyle != "relative" {
+		fmt.Fprintln(console, color.RedString("[ERR] Invalid output style: %s (absolute, relative)", outputStyle))
 		os.Exit(1)
 	}
 
-	color.Green("[INF] Scanning %s (Depth: %d)", u, d)
+	fmt.Fprintln(console, color.GreenString("[INF] Scanning %s (Depth: %d)", u, d))
 	if onlyExternal {
-		color.Yellow("[INF] Filter: External links only")
+		fmt.Fprintln(console, color.YellowString("[INF] Filter: External links only"))
 	}
 	if onlyInternal {
-		color.Yellow("[INF] Filter: Internal links only")
+		fmt.Fprintln(console, color.YellowString("[INF] Filter: Internal links only"))
 	}
 	if tree {
-		color.Magenta("[INF] Tree view enabled (Internal links)")
+		fmt.Fprintln(console, color.MagentaString("[INF] Tree view enabled (Internal links)"))
 	}
 	if output != "" {
-		color.Blue("[INF] Output will be saved to %s", output)
+		fmt.Fprintln(console, color.BlueString("[INF] Output will be saved to %s", output))
+	}
+	if tracePath != "" {
+		fmt.Fprintln(console, color.BlueString("[INF] Trace will be saved to %s", tracePath))
 	}
 
 	cfg := Config{
-		TargetURL:    u,
-		MaxDepth:     d,
-		OnlyInternal: onlyInternal,
-		OnlyExternal: onlyExternal,
-		OutputPath:   output,
-		Verbose:      verbose,
-		ShowTree:     tree,
+		TargetURL:           u,
+		MaxDepth:            d,
+		OnlyInternal:        onlyInternal,
+		OnlyExternal:        onlyExternal,
+		OutputPath:          output,
+		OutputDir:           outputDir,
+		Verbose:             verbose,
+		ShowTree:            tree,
+		DirStats:            dirStats,
+		ProbeSensitiveFiles: probeSensitive,
+		TracePath:           tracePath,
+		CustomPatterns:      patterns,
+		MaxResponseTime:     maxResponseTime,
+		MaxPages:            maxPages,
+		MinContentLength:    minContentLength,
+		AuthScheme:          authScheme,
+		AuthUser:            authUser,
+		AuthPassword:        authPassword,
+		RequestSigner:       signer,
+		Cookies:             cookieRules,
+		UserAgent:           userAgent,
+		ProxyURL:            proxyURL,
+		BlockPrivateIPs:     blockPrivate,
+		ExtractWithin:       within,
+		Keyword:             keyword,
+		KeywordWindow:       keywordWindow,
+		FollowPagination:    pagination || len(nextPatterns) > 0 || len(nextSelectors) > 0,
+		PaginationSelectors: nextSelectors,
+		ParseMode:           parseMode,
+		Render:              render || renderEndpoint != "",
+		RenderEndpoint:      renderEndpoint,
+		ExtractForms:        forms,
+		FetchTitles:         titles,
+		FlagRedirects:       redirects,
+		DefaultScheme:       scheme,
+		MaxConcurrentReads:  maxReads,
+		Canonical:           canonical,
+		CrawlWorkers:        crawlWorkers,
+		ValidationWorkers:   validationWorkers,
+		IdleTimeout:         idleTimeout,
+		RequireContent:      requireContent,
+		HonorCanonical:      honorCanonical,
+		Sitemap:             sitemap,
+		UseSitemap:          useSitemap,
+		Discrepancies:       discrepancies,
+		LinkRot:             linkRot,
+		RespectCrawlDelay:   crawlDelay,
+		RespectRobots:       respectRobots,
+		SkipProtectedHosts:  skipProtected,
+		Estimate:            estimate,
+		Benchmark:           benchmark,
+		MaxRuntimePerHost:   maxRuntimePerHost,
+		TraceMaxRedirects:   traceRedirects,
+		TraceSecrets:        traceSecrets,
+		MixedContent:        mixedContent,
+		ExtractIntegrity:    extractIntegrity,
+		ExtractOpenSearch:   openSearch,
+		BandwidthLimit:      bandwidthLimit,
+		RequestDelay:        requestDelay,
+		RequestsPerSecond:   requestsPerSecond,
+		AdaptiveConcurrency: adaptiveConcurrency,
+		BreakerThreshold:    breakerThreshold,
+		BreakerCooldown:     breakerCooldown,
+		WebhookURL:          webhookURL,
+		SampleRate:          sampleRate,
+		QueryAsChild:        queryAsChild,
+		MinURLLength:        minURLLength,
+		MaxPathDepth:        maxPathDepth,
+		IncludePatterns:     includes,
+		ExcludePatterns:     excludes,
+		HashRouteMode:       hashRouteMode,
+		ScopeMode:           scopeMode,
+		MaxMatchesPerDoc:    maxMatches,
+		StreamPath:          streamPath,
+		StatePath:           statePath,
+		AutoSaveInterval:    autoSave,
+		CrawlWindow:         window,
+		ValidationCachePath: validationCache,
+		ValidationCacheTTL:  validationCacheTTL,
+		OutputBuffer:        outputBuffer,
+		FlushInterval:       flushInterval,
+		Console:             console,
+		OutputStyle:         outputStyle,
+		Deterministic:       deterministic,
+		RampUp:              rampUp,
+	}
+
+	if seedFile != "" {
+		seeds, err := LoadSeeds(seedFile)
+		if err != nil {
+			fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+			os.Exit(1)
+		}
+		cfg.Seeds = seeds
+	}
+	if len(headers) > 0 {
+		cfg.Headers = make(map[string]string)
+		for _, h := range headers {
+			name, value, ok := strings.Cut(h, ":")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				fmt.Fprintln(console, color.RedString("[ERR] Invalid header %q, expected \"Name: value\"", h))
+				os.Exit(1)
+			}
+			cfg.Headers[name] = strings.TrimSpace(value)
+		}
+	}
+	if len(templates) > 0 {
+		cfg.Templates = templates
+		cfg.TemplateValues = make(map[string][]string)
+		for _, s := range templateValues {
+			name, values, err := ParseTemplateValues(s)
+			if err != nil {
+				fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+				os.Exit(1)
+			}
+			cfg.TemplateValues[name] = append(cfg.TemplateValues[name], values...)
+		}
+		for _, tmpl := range templates {
+			if _, err := ExpandTemplate(tmpl, cfg.TemplateValues); err != nil {
+				fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+				os.Exit(1)
+			}
+		}
+	}
+	if levels {
+		cfg.OnLevelComplete = func(depth int, results []Result) {
+			fmt.Fprintln(console, color.MagentaString("[LVL] Depth %d complete (%d results)", depth, len(results)))
+		}
+	}
+	if sensitiveFiles != "" {
+		cfg.SensitiveFiles = strings.Split(sensitiveFiles, ",")
+	}
+	if stripSessions {
+		cfg.SessionIDPatterns = append(cfg.SessionIDPatterns, DefaultSessionIDPatterns...)
+	}
+	cfg.SessionIDPatterns = append(cfg.SessionIDPatterns, sessionPatterns...)
+	if cfg.FollowPagination {
+		cfg.PaginationPatterns = append(cfg.PaginationPatterns, DefaultPaginationPatterns...)
+		cfg.PaginationPatterns = append(cfg.PaginationPatterns, nextPatterns...)
+	}
+	if externalTLDs != "" {
+		cfg.ExternalTLDs = strings.Split(externalTLDs, ",")
 	}
 
-	c := New(cfg)
-	if err := c.Start(); err != nil {
+	c, err := New(cfg)
+	if err != nil {
+		fmt.Fprintln(console, color.RedString("[ERR] %v", err))
+		os.Exit(1)
+	}
+	// Ctrl+C stops the crawl but still saves what was found, a second one exits
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	// Restores the default handler after the first signal, so the second kills the process
+	context.AfterFunc(ctx, stop)
+	summary, err := c.StartContext(ctx)
+	stop()
+	if err != nil {
+		if printSummary {
+			writeSummary(summary)
+		}
 		log.Fatalf("%s %v", color.RedString("[FATAL] Crawler failed:"), err)
 	}
 
 	if tree {
 		c.PrintTree()
 	}
+	if dirStats {
+		c.PrintDirStats()
+	}
+	c.PrintSitemapCoverage()
 
 	if output != "" {
 		if err := c.SaveJSON(); err != nil {
-			color.Red("[ERR] Failed to save output: %v", err)
+			fmt.Fprintln(console, color.RedString("[ERR] Failed to save output: %v", err))
+		} else {
+			fmt.Fprintln(console, color.GreenString("[INF] Saved results to %s", output))
+		}
+	}
+
+	if outputDir != "" {
+		if err := c.SaveSplit(); err != nil {
+			fmt.Fprintln(console, color.RedString("[ERR] Failed to save split output: %v", err))
+		} else {
+			fmt.Fprintln(console, color.GreenString("[INF] Saved categorized results to %s", outputDir))
+		}
+	}
+
+	if validationCache != "" {
+		if err := c.SaveValidationCache(); err != nil {
+			fmt.Fprintln(console, color.RedString("[ERR] Failed to save validation cache: %v", err))
+		}
+	}
+
+	if tracePath != "" {
+		if err := c.SaveTrace(); err != nil {
+			fmt.Fprintln(console, color.RedString("[ERR] Failed to save trace: %v", err))
 		} else {
-			color.Green("[INF] Saved results to %s", output)
+			fmt.Fprintln(console, color.GreenString("[INF] Saved trace to %s", tracePath))
 		}
 	}
+
+	if printSummary {
+		writeSummary(summary)
+		os.Exit(summary.ExitCode())
+	}
+}
+
+// writeSummary prints the summary as a single JSON line on stderr.
+func writeSummary(s *Summary) {
+	if err := json.NewEncoder(os.Stderr).Encode(s); err != nil {
+		fmt.Fprintln(color.Error, color.RedString("[ERR] Failed to write summary: %v", err))
+	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import "slices"
+
+// Merge folds the findings of other into c, so targets split across several
+// crawlers can be reported as one. URLs already known to c are kept once,
+// at the shallowest depth either crawler found them. other's target is kept
+// in MergedTargets, so its pages stay internal in the tree and reports.
+func (c *Crawler) Merge(other *Crawler) {
+	if other == nil || other == c {
+		return
+	}
+
+	other.Visited.Range(func(k, v any) bool {
+		c.Visited.LoadOrStore(k, v)
+		return true
+	})
+	other.validCache.Range(func(k, v any) bool {
+		c.validCache.LoadOrStore(k, v)
+		return true
+	})
+
+	broken := other.BrokenLinks()
+	other.resultsMu.Lock()
+	results := append([]Result(nil), other.Results...)
+	sensitive := append([]Result(nil), other.Sensitive...)
+	websockets := append([]Result(nil), other.WebSockets...)
+	emails := append([]Result(nil), other.Emails...)
+	routes := append([]Result(nil), other.HashRoutes...)
+	disallowed := append([]Result(nil), other.RobotsDisallowed...)
+	loops := append([]Result(nil), other.RedirectLoops...)
+	forms := append([]Form(nil), other.Forms...)
+	search := append([]SearchEndpoint(nil), other.OpenSearch...)
+	targets := append([]string{other.Config.TargetURL}, other.MergedTargets...)
+	other.resultsMu.Unlock()
+
+	c.resultsMu.Lock()
+	defer c.resultsMu.Unlock()
+
+	for _, t := range targets {
+		if t != c.Config.TargetURL && !slices.Contains(c.MergedTargets, t) {
+			c.MergedTargets = append(c.MergedTargets, t)
+		}
+	}
+
+	c.Results = mergeResults(c.Results, results)
+	c.resultIndex = make(map[string]int, len(c.Results))
+	for i, r := range c.Results {
+		c.resultIndex[r.URL] = i
+	}
+	c.Sensitive = mergeResults(c.Sensitive, sensitive)
+	c.WebSockets = mergeResults(c.WebSockets, websockets)
+	c.Emails = mergeResults(c.Emails, emails)
+	c.HashRoutes = mergeResults(c.HashRoutes, routes)
+	c.RobotsDisallowed = mergeResults(c.RobotsDisallowed, disallowed)
+	c.RedirectLoops = mergeResults(c.RedirectLoops, loops)
+	for _, l := range loops {
+		c.seenLoops.Store(l.URL, true)
+	}
+	for _, b := range broken {
+		if i, ok := c.brokenIndex[b.URL]; ok {
+			for _, from := range b.LinkedFrom {
+				if !slices.Contains(c.Broken[i].LinkedFrom, from) {
+					c.Broken[i].LinkedFrom = append(c.Broken[i].LinkedFrom, from)
+				}
+			}
+			continue
+		}
+		if c.brokenIndex == nil {
+			c.brokenIndex = make(map[string]int)
+		}
+		c.brokenIndex[b.URL] = len(c.Broken)
+		c.Broken = append(c.Broken, b)
+	}
+	for _, f := range forms {
+		if _, loaded := c.seenForms.LoadOrStore(f.Method+" "+f.Action, true); !loaded {
+			c.Forms = append(c.Forms, f)
+		}
+	}
+	for _, ep := range search {
+		if !slices.ContainsFunc(c.OpenSearch, func(e SearchEndpoint) bool {
+			return e.Template == ep.Template && e.Type == ep.Type
+		}) {
+			c.OpenSearch = append(c.OpenSearch, ep)
+		}
+	}
+}
+
+// mergeResults appends the entries of src missing from dst, replacing those
+// that src reached at a shallower depth.
+func mergeResults(dst, src []Result) []Result {
+	index := make(map[string]int, len(dst))
+	for i, r := range dst {
+		index[r.URL] = i
+	}
+	for _, r := range src {
+		i, ok := index[r.URL]
+		if !ok {
+			index[r.URL] = len(dst)
+			dst = append(dst, r)
+			continue
+		}
+		if r.Depth < dst[i].Depth {
+			dst[i] = r
+		}
+	}
+	return dst
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestMergeHosts(t *testing.T) {
+	site := func(page string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<a href="/%s">%s</a>`, page, page)
+		}))
+	}
+	a, b := site("a"), site("b")
+	defer a.Close()
+	defer b.Close()
+
+	ca := crawlTest(t, Config{TargetURL: a.URL})
+	cb := crawlTest(t, Config{TargetURL: b.URL})
+	ca.Merge(cb)
+
+	urls := resultURLs(ca)
+	for _, want := range []string{a.URL + "/a", b.URL + "/b"} {
+		if !slices.Contains(urls, want) {
+			t.Errorf("merged results miss %s: %v", want, urls)
+		}
+	}
+	if !slices.Equal(ca.MergedTargets, []string{cb.Config.TargetURL}) {
+		t.Errorf("MergedTargets = %v, want [%s]", ca.MergedTargets, cb.Config.TargetURL)
+	}
+	if domains := ca.ExternalDomains(); len(domains) != 0 {
+		t.Errorf("merged target reported as external: %v", domains)
+	}
+	bu, _ := url.Parse(b.URL)
+	node := ca.buildTree().Children[bu.Host]
+	if node == nil || node.Children["b"] == nil {
+		t.Errorf("merged target missing from the tree")
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// addMixedContent records the insecure sub-resources of an HTTPS page.
+func (c *Crawler) addMixedContent(resources []string, page string) {
+	for _, u := range resources {
+		if _, loaded := c.seenMixed.LoadOrStore(page+" "+u, true); loaded {
+			continue
+		}
+		c.printf("[%s] %s on %s\n", color.RedString("MIX"), u, c.formatResult(page))
+		c.resultsMu.Lock()
+		c.MixedContent = append(c.MixedContent, Result{
+			URL:          u,
+			FoundOn:      page,
+			DiscoveredAt: time.Now(),
+		})
+		c.resultsMu.Unlock()
+	}
+}
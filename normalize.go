@@ -0,0 +1,165 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// schemeRegex matches a scheme (RFC 3986 3.1) followed by "://" at the start
+// of a URL.
+var schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// hasScheme reports whether raw starts with a scheme such as "https://". A
+// "://" further in, e.g. in "example.com/?next=https://other", doesn't count.
+func hasScheme(raw string) bool {
+	return schemeRegex.MatchString(raw)
+}
+
+// normalizeURL returns the canonical string form of u used as the dedup key
+// in Visited and validCache, so that encoding variants of the same resource
+// collapse into a single entry. Scheme and host are lowercased, the path
+// keeps its case.
+func normalizeURL(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = hostKey(&n)
+	escaped := removeDotSegments(normalizePercent(n.EscapedPath()))
+	if decoded, err := url.PathUnescape(escaped); err == nil {
+		n.Path = decoded
+		n.RawPath = escaped
+	}
+	n.RawQuery = normalizePercent(n.RawQuery)
+	return n.String()
+}
+
+// removeDotSegments collapses "." and ".." path segments (RFC 3986 5.2.4).
+// Relative references are already resolved this way by url.Parse, but not
+// a URL given as-is nor dots that were percent-encoded. ".." never climbs
+// above the root, so a traversal can't leave the host it was found on.
+func removeDotSegments(p string) string {
+	if !strings.HasPrefix(p, "/") || !strings.Contains(p, ".") {
+		return p
+	}
+	segs := strings.Split(p, "/")
+	out := make([]string, 0, len(segs))
+	for i, s := range segs {
+		last := i == len(segs)-1
+		switch s {
+		case ".":
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, s)
+			continue
+		}
+		// A trailing dot segment leaves the path ending in a slash
+		if last {
+			out = append(out, "")
+		}
+	}
+	return strings.Join(out, "/")
+}
+
+// hostKey returns the host of u in the form used for comparisons: lowercase
+// punycode, without the scheme's default port.
+func hostKey(u *url.URL) string {
+	host := asciiHost(u.Host)
+	port := u.Port()
+	scheme := strings.ToLower(u.Scheme)
+	if (port == "80" && (scheme == "http" || scheme == "ws")) ||
+		(port == "443" && (scheme == "https" || scheme == "wss")) {
+		host = strings.TrimSuffix(host, ":"+port)
+	}
+	return host
+}
+
+// asciiHost converts an internationalized host to its lowercase punycode
+// form so the Unicode and "xn--" spellings of a domain compare equal. ASCII
+// hosts are only lowercased, hosts that IDNA rejects are returned unchanged.
+func asciiHost(host string) string {
+	ascii := true
+	for i := 0; i < len(host); i++ {
+		if host[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return strings.ToLower(host)
+	}
+
+	name, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		name, port = h, p
+	}
+	converted, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return host
+	}
+	if port != "" {
+		return net.JoinHostPort(converted, port)
+	}
+	return converted
+}
+
+// normalizePercent rewrites percent-escapes canonically: unreserved
+// characters (RFC 3986) are decoded and the remaining escapes use uppercase
+// hex digits. Escapes are decoded only once, so double-encoding is preserved.
+func normalizePercent(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			c := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(c) {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&15])
+			}
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+func isUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// pathDepth counts the non-empty segments of u's path: "/a/b/" and "/a//b"
+// are both 2 deep.
+func pathDepth(u *url.URL) int {
+	depth := 0
+	for _, seg := range strings.Split(u.Path, "/") {
+		if seg != "" {
+			depth++
+		}
+	}
+	return depth
+}
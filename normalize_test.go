@@ -0,0 +1,195 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+// normalized parses raw and returns its normalizeURL key.
+func normalized(t *testing.T, raw string) string {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %v", raw, err)
+	}
+	return normalizeURL(u)
+}
+
+func TestNormalizeURLPercent(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"http://a.test/path%20name", "http://a.test/path%20name"},
+		{"http://a.test/path name", "http://a.test/path%20name"},
+		{"http://a.test/caf%c3%a9", "http://a.test/caf%C3%A9"},
+		{"http://a.test/caf%C3%A9", "http://a.test/caf%C3%A9"},
+		{"http://a.test/%7Euser", "http://a.test/~user"},
+		{"http://a.test/%41%62c", "http://a.test/Abc"},
+		{"http://a.test/a%2fb", "http://a.test/a%2Fb"},
+		{"http://a.test/a%252fb", "http://a.test/a%252fb"},
+		{"http://a.test/a%2520b", "http://a.test/a%2520b"},
+		{"http://a.test/s?q=a%2fb&r=%7e", "http://a.test/s?q=a%2Fb&r=~"},
+	}
+	for _, tt := range tests {
+		if got := normalized(t, tt.in); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHasScheme(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"https://example.com", true},
+		{"HTTP://example.com", true},
+		{"svn+ssh://host/repo", true},
+		{"example.com", false},
+		{"example.com:8080/path", false},
+		{"example.com/?next=https://other.test", false},
+		{"example.com/redirect/http://other.test", false},
+		{"://example.com", false},
+		{"1http://example.com", false},
+	}
+	for _, tt := range tests {
+		if got := hasScheme(tt.in); got != tt.want {
+			t.Errorf("hasScheme(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHostKeyIDN(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"http://例え.jp/", "http://xn--r8jz45g.jp/"},
+		{"http://例え.JP/", "http://XN--R8JZ45G.jp/"},
+		{"https://bücher.example:8443/", "https://xn--bcher-kva.example:8443/"},
+		{"https://MÜNCHEN.de/", "https://xn--mnchen-3ya.de/"},
+	}
+	for _, tt := range tests {
+		a, _ := url.Parse(tt.a)
+		b, _ := url.Parse(tt.b)
+		if hostKey(a) != hostKey(b) {
+			t.Errorf("hostKey(%s) = %q, hostKey(%s) = %q", tt.a, hostKey(a), tt.b, hostKey(b))
+		}
+		if normalizeURL(a) != normalizeURL(b) {
+			t.Errorf("normalizeURL(%s) = %q, normalizeURL(%s) = %q", tt.a, normalizeURL(a), tt.b, normalizeURL(b))
+		}
+		for _, mode := range []string{ScopeHost, ScopeDomain} {
+			c := &Crawler{Config: Config{ScopeMode: mode}}
+			if !c.inScope(a, b) || !c.inScope(b, a) {
+				t.Errorf("%s and %s not in the same %s scope", tt.a, tt.b, mode)
+			}
+		}
+	}
+}
+
+func TestHostKeyDefaultPort(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"http://example.com:80/", "example.com"},
+		{"https://example.com:443/", "example.com"},
+		{"ws://example.com:80/", "example.com"},
+		{"wss://example.com:443/", "example.com"},
+		{"HTTPS://Example.com:443/", "example.com"},
+		{"http://example.com:443/", "example.com:443"},
+		{"https://example.com:80/", "example.com:80"},
+		{"https://example.com:8443/", "example.com:8443"},
+		{"http://[::1]:80/", "[::1]"},
+		{"http://[::1]:8080/", "[::1]:8080"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.in)
+		if got := hostKey(u); got != tt.want {
+			t.Errorf("hostKey(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if a, b := normalized(t, "https://example.com:443/a"), normalized(t, "https://example.com/a"); a != b {
+		t.Errorf("normalizeURL keeps the default port: %q != %q", a, b)
+	}
+}
+
+func TestRemoveDotSegments(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		// RFC 3986 5.2.4 and 5.4
+		{"/a/b/c/./../../g", "/a/g"},
+		{"/b/c/./g", "/b/c/g"},
+		{"/b/c/.", "/b/c/"},
+		{"/b/c/./", "/b/c/"},
+		{"/b/c/..", "/b/"},
+		{"/b/c/../", "/b/"},
+		{"/b/c/../g", "/b/g"},
+		{"/b/c/../..", "/"},
+		{"/b/c/../../g", "/g"},
+		// Never above the root
+		{"/b/c/../../../g", "/g"},
+		{"/../../../../etc/passwd", "/etc/passwd"},
+		{"/..", "/"},
+		// Dots inside names are not segments
+		{"/g.", "/g."},
+		{"/.g", "/.g"},
+		{"/g..", "/g.."},
+		{"/..g", "/..g"},
+		{"/a/file.tar.gz", "/a/file.tar.gz"},
+		// Left alone
+		{"", ""},
+		{"a/../b", "a/../b"},
+	}
+	for _, tt := range tests {
+		if got := removeDotSegments(tt.in); got != tt.want {
+			t.Errorf("removeDotSegments(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeURLTraversal(t *testing.T) {
+	base, _ := url.Parse("http://a.test/b/c/d;p?q")
+	tests := []struct {
+		ref, want string
+	}{
+		{"../../../g", "http://a.test/g"},
+		{"../../../../../g", "http://a.test/g"},
+		{"/../g", "http://a.test/g"},
+		{"http://a.test/x/%2e%2e/y", "http://a.test/y"},
+		{"http://a.test/x/%2E/y", "http://a.test/x/y"},
+	}
+	for _, tt := range tests {
+		u, err := base.Parse(tt.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := normalizeURL(u); got != tt.want {
+			t.Errorf("normalizeURL(%s) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeURLCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"HTTP://Example.COM/Path", "http://example.com/Path"},
+		{"hTTpS://WWW.Example.com:443/A/b?Q=V", "https://www.example.com/A/b?Q=V"},
+		{"HTTPS://EXAMPLE.COM:8443/", "https://example.com:8443/"},
+		{"WSS://Example.com/Socket", "wss://example.com/Socket"},
+	}
+	for _, tt := range tests {
+		if got := normalized(t, tt.in); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	base, _ := url.Parse("http://example.com/")
+	c := &Crawler{}
+	for _, raw := range []string{"HTTP://EXAMPLE.COM/x", "http://Example.Com:80/y"} {
+		u, _ := url.Parse(raw)
+		if !c.inScope(u, base) {
+			t.Errorf("%s not in scope of %s", raw, base)
+		}
+	}
+}
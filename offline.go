@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// localRoot returns the directory a file:// target is crawled offline from:
+// the target itself when it is a directory, its parent otherwise. ok is
+// false for other targets.
+func localRoot(target string) (root string, ok bool) {
+	u, err := url.Parse(target)
+	if err != nil || !strings.EqualFold(u.Scheme, "file") {
+		return "", false
+	}
+	root = path.Clean("/" + u.Path)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		root = path.Dir(root)
+	}
+	return root, true
+}
+
+// fileTransport serves file:// URLs from the local filesystem so saved
+// dumps, gzipped or not, go through the same extraction as a live site.
+// Files outside root are refused, so a dump can't lead the crawl through
+// the rest of the disk.
+type fileTransport struct {
+	root string
+	next http.RoundTripper
+}
+
+func newFileTransport(root string) fileTransport {
+	return fileTransport{root: root, next: http.NewFileTransport(http.Dir("/"))}
+}
+
+func (t fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := path.Clean("/" + req.URL.Path)
+	if p != t.root && !strings.HasPrefix(p, strings.TrimSuffix(t.root, "/")+"/") {
+		return nil, fmt.Errorf("%s is outside %s", p, t.root)
+	}
+	return t.next.RoundTrip(req)
+}
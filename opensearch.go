@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/net/html"
+)
+
+const openSearchType = "application/opensearchdescription+xml"
+
+// SearchEndpoint is a search URL template declared by an OpenSearch
+// description document.
+type SearchEndpoint struct {
+	Template    string `json:"template"`
+	Type        string `json:"type,omitempty"`   // Response media type, e.g. text/html or application/json
+	Method      string `json:"method,omitempty"` // GET when empty
+	ShortName   string `json:"short_name,omitempty"`
+	Description string `json:"description"` // URL of the description document
+	FoundOn     string `json:"found_on,omitempty"`
+}
+
+type openSearchDoc struct {
+	ShortName string `xml:"ShortName"`
+	URLs      []struct {
+		Type     string `xml:"type,attr"`
+		Method   string `xml:"method,attr"`
+		Template string `xml:"template,attr"`
+	} `xml:"Url"`
+}
+
+// OpenSearchLinks returns the hrefs of the document's
+// <link rel="search" type="application/opensearchdescription+xml">.
+// Scanning stops at the body.
+func OpenSearchLinks(content string) []string {
+	var found []string
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return found
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		switch string(name) {
+		case "body":
+			return found
+		case "link":
+		default:
+			continue
+		}
+
+		var rel, typ, href string
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			switch string(key) {
+			case "rel":
+				rel = string(val)
+			case "type":
+				typ = string(val)
+			case "href":
+				href = string(val)
+			}
+		}
+		if slices.Contains(strings.Fields(strings.ToLower(rel)), "search") &&
+			strings.EqualFold(strings.TrimSpace(typ), openSearchType) && strings.TrimSpace(href) != "" {
+			found = append(found, strings.TrimSpace(href))
+		}
+	}
+}
+
+// addOpenSearch fetches the description documents linked from page, once
+// each, and records the search endpoints they declare.
+func (c *Crawler) addOpenSearch(hrefs []string, page *url.URL) {
+	for _, href := range hrefs {
+		desc, err := page.Parse(href)
+		if err != nil || (desc.Scheme != "http" && desc.Scheme != "https") {
+			continue
+		}
+		descURL := normalizeURL(desc)
+		if _, loaded := c.seenOpenSearch.LoadOrStore(descURL, true); loaded {
+			continue
+		}
+
+		doc, err := c.fetchOpenSearch(descURL)
+		if err != nil {
+			if c.Config.Verbose {
+				c.printf("[%s] %s: %v\n", color.RedString("ERR"), descURL, err)
+			}
+			continue
+		}
+		for _, u := range doc.URLs {
+			if strings.TrimSpace(u.Template) == "" {
+				continue
+			}
+			ep := SearchEndpoint{
+				Template:    strings.TrimSpace(u.Template),
+				Type:        u.Type,
+				Method:      strings.ToUpper(u.Method),
+				ShortName:   strings.TrimSpace(doc.ShortName),
+				Description: descURL,
+				FoundOn:     page.String(),
+			}
+			c.printf("[%s] %s\n", color.BlueString("OSD"), ep.Template)
+			c.resultsMu.Lock()
+			c.OpenSearch = append(c.OpenSearch, ep)
+			c.resultsMu.Unlock()
+		}
+	}
+}
+
+func (c *Crawler) fetchOpenSearch(descURL string) (*openSearchDoc, error) {
+	req, err := c.newRequest("GET", descURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(c.FastClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("description returned %s", resp.Status)
+	}
+	var doc openSearchDoc
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
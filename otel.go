@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startCrawlSpan opens the span covering the whole crawl when a Tracer is
+// configured. The returned function ends it.
+func (c *Crawler) startCrawlSpan(target string) func() {
+	if c.Config.Tracer == nil {
+		return func() {}
+	}
+	ctx, span := c.Config.Tracer.Start(context.Background(), "crawl",
+		trace.WithAttributes(
+			attribute.String("crawl.target", target),
+			attribute.Int("crawl.max_depth", c.Config.MaxDepth),
+			attribute.String("crawl.run_id", c.RunID),
+		))
+	c.spanCtx = ctx
+	return func() {
+		span.SetAttributes(attribute.Int("crawl.results", len(c.Results)))
+		span.End()
+	}
+}
+
+// startRequestSpan opens a child span of the crawl for one HTTP request.
+// The returned function ends it with the outcome of the request.
+func (c *Crawler) startRequestSpan(req *http.Request) func(*http.Response, error) {
+	if c.Config.Tracer == nil {
+		return func(*http.Response, error) {}
+	}
+	parent := c.spanCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	_, span := c.Config.Tracer.Start(parent, req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+			attribute.String("server.address", req.URL.Hostname()),
+		))
+	return func(resp *http.Response, err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+		}
+		span.End()
+	}
+}
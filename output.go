@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// defaultFlushInterval bounds how stale buffered output may get.
+const defaultFlushInterval = time.Second
+
+// printf writes a line of crawl output. With OutputBuffer set, lines are
+// buffered and flushed when the buffer fills, every FlushInterval, and when
+// the crawl ends.
+func (c *Crawler) printf(format string, a ...any) {
+	if c.out == nil {
+		fmt.Fprintf(c.console(), format, a...)
+		return
+	}
+	c.outMu.Lock()
+	fmt.Fprintf(c.out, format, a...)
+	c.outMu.Unlock()
+}
+
+// Flush writes out any buffered crawl output.
+func (c *Crawler) Flush() error {
+	if c.out == nil {
+		return nil
+	}
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	return c.out.Flush()
+}
+
+// flushEvery flushes buffered output periodically until done is closed.
+func (c *Crawler) flushEvery(done <-chan struct{}) {
+	interval := c.Config.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.Flush()
+		}
+	}
+}
+
+// console is where progress, warnings and reports go: Config.Console, or
+// stdout when unset.
+func (c *Crawler) console() io.Writer {
+	if c.Config.Console != nil {
+		return c.Config.Console
+	}
+	return color.Output
+}
+
+// logf writes a status line such as "[WRN] ..." to the console, colored by
+// paint.
+func (c *Crawler) logf(paint func(string, ...any) string, format string, a ...any) {
+	fmt.Fprintln(c.console(), paint(format, a...))
+}
+
+func newOutput(w io.Writer, size int) *bufio.Writer {
+	if size <= 0 {
+		return nil
+	}
+	return bufio.NewWriterSize(w, size)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// maxPaginationPages bounds the pages followed as pagination over a crawl,
+// since they don't consume depth and an endless "next" chain (calendars,
+// generated listings) would otherwise never stop.
+const maxPaginationPages = 1000
+
+// DefaultPaginationPatterns match the text of common "next page" links.
+var DefaultPaginationPatterns = []string{
+	`(?i)^(next|suivant|suivante|older)\b`,
+	`^[»›→]+$`,
+}
+
+var anchorSel = cascadia.MustCompile("a[href]")
+
+// linkHeaderNext returns the targets of rel="next" entries of the Link
+// response headers.
+func linkHeaderNext(h http.Header) []string {
+	var next []string
+	for _, header := range h.Values("Link") {
+		for _, entry := range strings.Split(header, ",") {
+			target, params, ok := strings.Cut(entry, ";")
+			target = strings.TrimSpace(target)
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, p := range strings.Split(params, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(p), "=")
+				rels := strings.Fields(strings.ToLower(strings.Trim(value, `"`)))
+				if strings.EqualFold(key, "rel") && slices.Contains(rels, "next") {
+					next = append(next, target[1:len(target)-1])
+				}
+			}
+		}
+	}
+	return next
+}
+
+// NextLinks returns the "next page" links of an HTML document. Elements
+// with rel="next" win; without any, the links matched by one of the
+// selectors, or whose text matches one of the patterns, are returned.
+func NextLinks(content string, patterns []*regexp.Regexp, selectors []cascadia.Sel) []string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+
+	var rel, guessed linkSet
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "link") {
+			href := strings.TrimSpace(attr(n, "href"))
+			if href != "" && slices.Contains(strings.Fields(strings.ToLower(attr(n, "rel"))), "next") {
+				rel.add(href)
+			} else if href != "" && n.Data == "a" && matchesAny(patterns, strings.TrimSpace(textContent(n))) {
+				guessed.add(href)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	if len(rel.found) > 0 {
+		return rel.found
+	}
+
+	for _, sel := range selectors {
+		for _, n := range cascadia.QueryAll(doc, sel) {
+			if n.Data != "a" {
+				if n = cascadia.Query(n, anchorSel); n == nil {
+					continue
+				}
+			}
+			if href := strings.TrimSpace(attr(n, "href")); href != "" {
+				guessed.add(href)
+			}
+		}
+	}
+	return guessed.found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// followPagination records the internal next-page links of page and crawls
+// them at the same depth, so long listings are traversed whatever MaxDepth.
+// It runs before the page's other links are recorded, claiming the pages
+// so they are tagged as pagination.
+func (c *Crawler) followPagination(next []string, page *url.URL, depth int) {
+	for _, href := range next {
+		target, err := page.Parse(href)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || !c.inScope(target, page) {
+			continue
+		}
+		if c.Config.MaxPathDepth > 0 && pathDepth(target) > c.Config.MaxPathDepth {
+			continue
+		}
+		abs := normalizeURL(c.applyHashRouteMode(c.stripSessionIDs(target), page.String()))
+		if !c.urlAllowed(abs) {
+			continue
+		}
+		// Past the limit, next links are left to the regular depth-bound crawl
+		if c.paginated.Load() >= maxPaginationPages {
+			return
+		}
+		if _, loaded := c.Visited.LoadOrStore(abs, true); loaded {
+			continue
+		}
+		if c.isBlockedHost(target.Hostname()) {
+			c.filterOut(abs, errBlockedHost.Error())
+			continue
+		}
+		if !c.robotsAllowed(target) {
+			c.addRobotsDisallowed(abs, page.String())
+			continue
+		}
+		c.paginated.Add(1)
+
+		v := c.validateLink(abs)
+		if !v.Valid {
+			if _, checked := c.validCache.Load(abs); checked {
+				c.addBrokenLink(abs, v.Status, page.String())
+			}
+			c.filterOut(abs, "pagination link failed validation")
+			continue
+		}
+		recurse := !c.stopped() && !c.hostExpired(page.Host)
+		if !c.Config.OnlyExternal {
+			li := v.link(abs, false)
+			li.pagination = true
+			if recurse {
+				c.holdResult(li, page.String(), depth)
+			} else {
+				c.addResult(li, page.String(), depth)
+			}
+		}
+		if recurse {
+			c.schedule(abs, depth)
+		}
+	}
+}
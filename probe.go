@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// defaultSensitiveFiles are probed in each directory when ProbeSensitiveFiles
+// is set and no custom list is configured.
+var defaultSensitiveFiles = []string{
+	".git/config",
+	".svn/entries",
+	".env",
+	".htaccess",
+	".DS_Store",
+	"web.config",
+	"backup.zip",
+	"backup.tar.gz",
+	"database.sql",
+	"config.php.bak",
+}
+
+// probeSensitive checks every internal directory seen during the crawl for
+// well-known sensitive files, recording the ones answering with a 2xx.
+func (c *Crawler) probeSensitive() {
+	files := c.Config.SensitiveFiles
+	if len(files) == 0 {
+		files = defaultSensitiveFiles
+	}
+
+	var wg sync.WaitGroup
+	for _, dir := range c.internalDirectories() {
+		for _, name := range files {
+			candidate := dir + strings.TrimPrefix(name, "/")
+			if _, loaded := c.Visited.LoadOrStore(candidate, true); loaded {
+				continue
+			}
+			if u, err := url.Parse(candidate); err == nil && !c.robotsAllowed(u) {
+				c.addRobotsDisallowed(candidate, dir)
+				continue
+			}
+
+			wg.Add(1)
+			go func(u, foundOn string) {
+				defer wg.Done()
+				if !c.acquire(c.validateSem) {
+					return
+				}
+				defer func() { <-c.validateSem }()
+
+				v := c.validateLink(u)
+				if v.Status < 200 || v.Status >= 300 {
+					c.filterOut(u, fmt.Sprintf("sensitive file probe returned status %d", v.Status))
+					return
+				}
+				c.printf("[%s] %s\n", color.RedString("SEN"), c.formatResult(u))
+				c.resultsMu.Lock()
+				c.Sensitive = append(c.Sensitive, Result{
+					URL:          u,
+					Status:       v.Status,
+					FoundOn:      foundOn,
+					DiscoveredAt: time.Now(),
+				})
+				c.resultsMu.Unlock()
+			}(candidate, dir)
+		}
+	}
+	wg.Wait()
+}
+
+// internalDirectories returns the target root and every directory (including
+// ancestors) of the in-scope results, as absolute URLs ending with a slash.
+// Each directory stays on the host it was seen on.
+func (c *Crawler) internalDirectories() []string {
+	rootURL, err := url.Parse(c.Config.TargetURL)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	add := func(base *url.URL, p string) {
+		d := url.URL{Scheme: base.Scheme, Host: base.Host, Path: p}
+		seen[d.String()] = true
+	}
+	add(rootURL, "/")
+	for _, r := range c.Results {
+		u, err := url.Parse(r.URL)
+		if err != nil || !c.inScope(u, rootURL) {
+			continue
+		}
+		add(u, "/")
+		parts := strings.Split(u.Path, "/")
+		for i := 1; i < len(parts)-1; i++ {
+			if parts[i] == "" {
+				continue
+			}
+			add(u, strings.Join(parts[:i+1], "/")+"/")
+		}
+	}
+
+	dirs := make([]string, 0, len(seen))
+	for d := range seen {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// ParseProxyURL parses an http://, https:// or socks5:// proxy URL.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy scheme %q (http, https, socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// newTransport builds the transport shared by Client and FastClient, routed
+// through ProxyURL when set. HTTP proxies go through Transport.Proxy, SOCKS5
+// ones replace the dialer. A file:// target also gets file:// support.
+func newTransport(cfg Config, insecure bool) (*http.Transport, error) {
+	dialer := newDialer(cfg)
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: insecure},
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     20,
+		IdleConnTimeout:     30 * time.Second,
+		DisableKeepAlives:   false,
+	}
+	if root, ok := localRoot(cfg.TargetURL); ok {
+		transport.RegisterProtocol("file", newFileTransport(root))
+	}
+	if cfg.ProxyURL == "" {
+		return transport, nil
+	}
+
+	u, err := ParseProxyURL(cfg.ProxyURL)
+	if err != nil {
+		return transport, err
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		transport.Proxy = http.ProxyURL(u)
+		return transport, nil
+	}
+	socks, err := proxy.FromURL(u, dialer)
+	if err != nil {
+		return transport, err
+	}
+	transport.DialContext = socks.(proxy.ContextDialer).DialContext
+	return transport, nil
+}
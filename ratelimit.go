@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/url"
+
+	"golang.org/x/time/rate"
+)
+
+// hostLimit returns the request rate allowed to a single host by
+// RequestsPerSecond and RequestDelay, the stricter winning, or rate.Inf
+// when neither is set.
+func (c *Crawler) hostLimit() rate.Limit {
+	limit := rate.Inf
+	if c.Config.RequestsPerSecond > 0 {
+		limit = rate.Limit(c.Config.RequestsPerSecond)
+	}
+	if c.Config.RequestDelay > 0 {
+		limit = min(limit, rate.Every(c.Config.RequestDelay))
+	}
+	return limit
+}
+
+// waitHostRate blocks until the host of u may receive another request.
+// Each host has its own bucket, so slow targets never hold back requests
+// to other hosts.
+func (c *Crawler) waitHostRate(u *url.URL) {
+	limit := c.hostLimit()
+	if limit == rate.Inf || u.Host == "" {
+		return
+	}
+	v, _ := c.limiters.LoadOrStore(u.Host, rate.NewLimiter(limit, 1))
+	v.(*rate.Limiter).Wait(c.context())
+}
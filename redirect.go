@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// maxRedirects mirrors the net/http default redirect limit.
+const maxRedirects = 10
+
+var errRedirectLoop = errors.New("redirect loop")
+
+// checkRedirect stops redirect chains that come back to a URL already
+// visited in the same request, reporting them as loops rather than letting
+// them run into the generic redirect limit. Credentials and custom headers
+// are dropped when a hop leaves the target's scope. Through a proxy the
+// dialer can't see private addresses, so each hop is checked here instead.
+func (c *Crawler) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.Config.ProxyURL != "" && c.isBlockedHost(req.URL.Hostname()) {
+		return fmt.Errorf("redirect to private address %s blocked", req.URL.Hostname())
+	}
+	if !c.inScope(req.URL, c.targetURL()) {
+		req.Header.Del("Authorization")
+		for name := range c.Config.Headers {
+			req.Header.Del(name)
+		}
+	}
+	target := req.URL.String()
+	for i, prev := range via {
+		if prev.URL.String() != target {
+			continue
+		}
+		chain := make([]string, 0, len(via)-i+1)
+		for _, v := range via[i:] {
+			chain = append(chain, v.URL.String())
+		}
+		chain = append(chain, target)
+		return fmt.Errorf("%w: %s", errRedirectLoop, strings.Join(chain, " -> "))
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+// addRedirectLoop records a URL whose redirects loop back on themselves.
+func (c *Crawler) addRedirectLoop(u, foundOn string) {
+	if _, loaded := c.seenLoops.LoadOrStore(u, true); loaded {
+		return
+	}
+	c.printf("[%s] %s\n", color.RedString("LOOP"), c.formatResult(u))
+	c.resultsMu.Lock()
+	c.RedirectLoops = append(c.RedirectLoops, Result{
+		URL:          u,
+		FoundOn:      foundOn,
+		DiscoveredAt: time.Now(),
+	})
+	c.resultsMu.Unlock()
+}
+
+// firstRedirect returns the status and resolved Location of the first
+// redirect followed to produce resp, or 0 when the request wasn't
+// redirected.
+func firstRedirect(resp *http.Response) (int, string) {
+	var hop *http.Response
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		hop = req.Response
+	}
+	if hop == nil {
+		return 0, ""
+	}
+	location := hop.Header.Get("Location")
+	if loc, err := hop.Location(); err == nil {
+		location = loc.String()
+	}
+	return hop.StatusCode, location
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckRedirectLoop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, "/a", http.StatusFound)
+		case "/chain":
+			http.Redirect(w, r, "/chain?n=x"+r.URL.Query().Get("n"), http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<a href="/a">loop</a>`)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{TargetURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		loop bool
+	}{
+		{"/a", true},
+		{"/chain", false}, // Never revisits a URL, stopped by the redirect limit
+	}
+	for _, tt := range tests {
+		_, err := c.Client.Get(srv.URL + tt.path)
+		if err == nil {
+			t.Errorf("GET %s: no error", tt.path)
+			continue
+		}
+		if got := errors.Is(err, errRedirectLoop); got != tt.loop {
+			t.Errorf("GET %s: loop = %v, want %v (%v)", tt.path, got, tt.loop, err)
+		}
+	}
+
+	c = crawlTest(t, Config{TargetURL: srv.URL})
+	if len(c.RedirectLoops) != 1 || c.RedirectLoops[0].URL != srv.URL+"/a" {
+		t.Errorf("RedirectLoops = %v, want %s/a", c.RedirectLoops, srv.URL)
+	}
+}
+
+func TestCheckRedirectPrivateThroughProxy(t *testing.T) {
+	c, err := New(Config{TargetURL: "http://93.184.215.14/", ProxyURL: "http://127.0.0.1:8080", BlockPrivateIPs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"http://10.0.0.1/admin", true},
+		{"http://127.0.0.1:8080/", true},
+		{"http://93.184.215.14/page", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.url, nil)
+		if err := c.checkRedirect(req, nil); (err != nil) != tt.blocked {
+			t.Errorf("checkRedirect(%s) = %v, want blocked %v", tt.url, err, tt.blocked)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	maxRenderTabs  = 4                      // Pages rendered at once
+	renderTimeout  = 30 * time.Second       // Navigation and load of a single page
+	renderSettle   = 500 * time.Millisecond // Wait after load for scripts to insert links
+	browserStartup = 20 * time.Second
+)
+
+// browserNames are looked up in PATH when no RenderEndpoint is configured.
+var browserNames = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome", "headless-shell"}
+
+var devtoolsListening = regexp.MustCompile(`DevTools listening on ws://([^/\s]+)/`)
+
+// renderer loads pages in a headless browser over the Chrome DevTools
+// Protocol and returns their DOM once scripts have run. The browser makes
+// its own requests: it doesn't send the crawler's credentials or headers and
+// bypasses BlockPrivateIPs for the resources pages load.
+type renderer struct {
+	endpoint string // http://host:port of the DevTools HTTP interface
+	client   *http.Client
+	cmd      *exec.Cmd
+	dataDir  string
+	tabs     chan struct{}
+}
+
+type cdpTarget struct {
+	ID                   string `json:"id"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+type cdpMessage struct {
+	ID     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params any             `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// newRenderer connects to the DevTools endpoint, e.g. http://127.0.0.1:9222,
+// or launches a local headless Chrome or Chromium when endpoint is empty.
+func newRenderer(endpoint string) (*renderer, error) {
+	r := &renderer{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		tabs:     make(chan struct{}, maxRenderTabs),
+	}
+	if r.endpoint == "" {
+		if err := r.launch(); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := r.client.Get(r.endpoint + "/json/version")
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("browser endpoint: %w", err)
+	}
+	resp.Body.Close()
+	return r, nil
+}
+
+func (r *renderer) launch() error {
+	var browser string
+	for _, name := range browserNames {
+		if path, err := exec.LookPath(name); err == nil {
+			browser = path
+			break
+		}
+	}
+	if browser == "" {
+		return errors.New("no Chrome or Chromium found in PATH, set a DevTools endpoint instead")
+	}
+
+	dataDir, err := os.MkdirTemp("", "yg-scovery-browser-")
+	if err != nil {
+		return err
+	}
+	r.dataDir = dataDir
+	args := []string{
+		"--headless=new", "--disable-gpu", "--no-first-run", "--mute-audio",
+		"--remote-debugging-port=0", "--user-data-dir=" + dataDir,
+	}
+	if os.Geteuid() == 0 {
+		// Chrome refuses to start as root with its sandbox on
+		args = append(args, "--no-sandbox")
+	}
+	r.cmd = exec.Command(browser, append(args, "about:blank")...)
+	stderr, err := r.cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := r.cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", browser, err)
+	}
+
+	found := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			if m := devtoolsListening.FindStringSubmatch(scanner.Text()); m != nil {
+				found <- m[1]
+				break
+			}
+		}
+		// Keep draining so the browser never blocks on a full pipe
+		for scanner.Scan() {
+		}
+	}()
+	select {
+	case addr := <-found:
+		r.endpoint = "http://" + addr
+		return nil
+	case <-time.After(browserStartup):
+		r.Close()
+		return fmt.Errorf("%s did not expose DevTools within %s", browser, browserStartup)
+	}
+}
+
+// Close stops the browser launched by newRenderer, if any.
+func (r *renderer) Close() {
+	if r.cmd != nil && r.cmd.Process != nil {
+		r.cmd.Process.Kill()
+		r.cmd.Wait()
+	}
+	if r.dataDir != "" {
+		os.RemoveAll(r.dataDir)
+	}
+}
+
+// render opens pageURL in a new tab and returns the rendered document.
+func (r *renderer) render(pageURL string) (string, error) {
+	r.tabs <- struct{}{}
+	defer func() { <-r.tabs }()
+
+	req, err := http.NewRequest(http.MethodPut, r.endpoint+"/json/new?about:blank", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	var target cdpTarget
+	err = json.NewDecoder(resp.Body).Decode(&target)
+	resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("opening tab: %w", err)
+	}
+	defer func() {
+		if resp, err := r.client.Get(r.endpoint + "/json/close/" + url.PathEscape(target.ID)); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	ws, err := websocket.Dial(target.WebSocketDebuggerURL, "", r.endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer ws.Close()
+	ws.MaxPayloadBytes = maxBodySize
+	ws.SetDeadline(time.Now().Add(renderTimeout))
+
+	if _, err := cdpCall(ws, 1, "Page.enable", nil); err != nil {
+		return "", err
+	}
+	res, err := cdpCall(ws, 2, "Page.navigate", map[string]string{"url": pageURL})
+	if err != nil {
+		return "", err
+	}
+	var nav struct {
+		ErrorText string `json:"errorText"`
+	}
+	if json.Unmarshal(res, &nav) == nil && nav.ErrorText != "" {
+		return "", errors.New(nav.ErrorText)
+	}
+	if err := cdpWaitEvent(ws, "Page.loadEventFired"); err != nil {
+		return "", err
+	}
+	time.Sleep(renderSettle)
+
+	res, err = cdpCall(ws, 3, "Runtime.evaluate", map[string]any{
+		"expression":    "document.documentElement.outerHTML",
+		"returnByValue": true,
+	})
+	if err != nil {
+		return "", err
+	}
+	var eval struct {
+		Result struct {
+			Value string `json:"value"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(res, &eval); err != nil {
+		return "", err
+	}
+	return eval.Result.Value, nil
+}
+
+// cdpCall sends a command and returns its result, skipping the events
+// received in between.
+func cdpCall(ws *websocket.Conn, id int, method string, params any) (json.RawMessage, error) {
+	if err := websocket.JSON.Send(ws, cdpMessage{ID: id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+	for {
+		var msg cdpMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return nil, err
+		}
+		if msg.ID != id {
+			continue
+		}
+		if msg.Error != nil {
+			return nil, fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		return msg.Result, nil
+	}
+}
+
+func cdpWaitEvent(ws *websocket.Conn, method string) error {
+	for {
+		var msg cdpMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return err
+		}
+		if msg.Method == method {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import "sync"
+
+// Reset clears everything a crawl accumulated so the Crawler, with its
+// configuration, clients and transport, can be started again on another
+// target. It must not be called while Start is running.
+func (c *Crawler) Reset() {
+	for _, m := range []*sync.Map{
+		&c.Visited, &c.validCache, &c.hostBlocked, &c.seenForms, &c.seenLoops,
+		&c.protected, &c.seenMixed, &c.seenSRI, &c.seenOpenSearch, &c.hostDeadlines, &c.timeCapped, &c.pacers, &c.limiters, &c.breakers,
+		&c.filtered, &c.crawled, &c.frontier, &c.requeued, &c.held,
+	} {
+		m.Clear()
+	}
+
+	c.resultsMu.Lock()
+	c.Results = nil
+	c.resultIndex = nil
+	c.Sensitive = nil
+	c.Forms = nil
+	c.WebSockets = nil
+	c.Emails = nil
+	c.HashRoutes = nil
+	c.OpenSearch = nil
+	c.RobotsDisallowed = nil
+	c.RedirectLoops = nil
+	c.Broken = nil
+	c.brokenIndex = nil
+	c.Protected = nil
+	c.TimeCapped = nil
+	c.MixedContent = nil
+	c.Subresources = nil
+	c.MergedTargets = nil
+	c.resultsMu.Unlock()
+
+	c.traceMu.Lock()
+	c.trace = nil
+	c.traceMu.Unlock()
+
+	c.rampMu.Lock()
+	c.inFlight = 0
+	c.rampMu.Unlock()
+
+	c.levelMu.Lock()
+	c.levelPending = make(map[int]int)
+	c.nextLevel = 0
+	c.levelMu.Unlock()
+
+	c.RunID = ""
+	c.Metadata = Metadata{}
+	c.sitemapURLs = nil
+	c.spanCtx = nil
+	c.ctx = nil
+	c.halted.Store(false)
+	c.lastResult.Store(0)
+	c.paginated.Store(0)
+	c.pagesFetched.Store(0)
+	c.pagesStarted.Store(0)
+	c.bytesRead.Store(0)
+	c.requests.Store(0)
+	c.requestErrors.Store(0)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// maxCrawlDelay caps Crawl-delay values so a hostile robots.txt can't stall
+// the crawl indefinitely.
+const maxCrawlDelay = time.Minute
+
+// robotsRules holds the robots.txt directives that apply to the crawler.
+type robotsRules struct {
+	CrawlDelay time.Duration
+	Rules      []robotsRule
+}
+
+// robotsRule is an Allow or Disallow line. Patterns may use "*" wildcards
+// and a trailing "$" anchor.
+type robotsRule struct {
+	Allow   bool
+	Pattern string
+}
+
+// allowed applies the longest matching rule to the path and query of u, an
+// Allow winning ties. URLs no rule matches are allowed.
+func (r robotsRules) allowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	allow, longest := true, -1
+	for _, rule := range r.Rules {
+		if !robotsMatch(rule.Pattern, path) {
+			continue
+		}
+		if n := len(rule.Pattern); n > longest || (n == longest && rule.Allow) {
+			allow, longest = rule.Allow, n
+		}
+	}
+	return allow
+}
+
+// robotsMatch reports whether a robots.txt path pattern matches path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return !anchored || pos == len(path)
+}
+
+// hostPacer holds the robots.txt rules of a single host and spaces out
+// requests to it.
+type hostPacer struct {
+	once  sync.Once
+	rules robotsRules
+	mu    sync.Mutex
+	next  time.Time
+}
+
+// parseRobots extracts the rules of the groups naming agent, the product
+// token of the crawler's User-Agent, or of the "*" groups when none does.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var named, wildcard robotsRules
+	var matchesNamed, matchesWildcard, hasNamed bool
+	inRules := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxBodySize))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			// A user-agent line after rules starts a new group
+			if inRules {
+				matchesNamed, matchesWildcard = false, false
+				inRules = false
+			}
+			if agent != "" && strings.EqualFold(value, agent) {
+				matchesNamed, hasNamed = true, true
+			}
+			matchesWildcard = matchesWildcard || value == "*"
+			continue
+		}
+		inRules = true
+		if matchesNamed {
+			named.apply(key, value)
+		}
+		if matchesWildcard {
+			wildcard.apply(key, value)
+		}
+	}
+	if hasNamed {
+		return named
+	}
+	return wildcard
+}
+
+// apply adds a directive of a group that applies to the crawler.
+func (r *robotsRules) apply(key, value string) {
+	switch key {
+	case "crawl-delay":
+		if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+			r.CrawlDelay = min(time.Duration(secs*float64(time.Second)), maxCrawlDelay)
+		}
+	case "allow", "disallow":
+		// An empty Disallow allows everything, like no rule at all
+		if value != "" {
+			r.Rules = append(r.Rules, robotsRule{Allow: key == "allow", Pattern: value})
+		}
+	}
+}
+
+// fetchRobots retrieves and parses robots.txt for the host of u. A missing
+// or unreadable file yields no rules.
+func (c *Crawler) fetchRobots(u *url.URL) robotsRules {
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := c.newRequest("GET", robotsURL.String())
+	if err != nil {
+		return robotsRules{}
+	}
+	resp, err := c.send(c.FastClient, req)
+	if err != nil {
+		return robotsRules{}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return robotsRules{}
+	}
+	agent, _, _ := strings.Cut(c.userAgent(), "/")
+	return parseRobots(resp.Body, agent)
+}
+
+// pacer returns the pacer of the host of u, fetching its robots.txt on
+// first contact.
+func (c *Crawler) pacer(u *url.URL) *hostPacer {
+	v, _ := c.pacers.LoadOrStore(u.Host, &hostPacer{})
+	p := v.(*hostPacer)
+	p.once.Do(func() {
+		p.rules = c.fetchRobots(u)
+	})
+	return p
+}
+
+// robotsAllowed reports whether robots.txt lets the crawler request u. It
+// is always true unless RespectRobots is set.
+func (c *Crawler) robotsAllowed(u *url.URL) bool {
+	if !c.Config.RespectRobots || u.Host == "" {
+		return true
+	}
+	return c.pacer(u).rules.allowed(u)
+}
+
+// waitCrawlDelay blocks until the host of u may be requested again under
+// its robots.txt Crawl-delay, or until the crawl is cancelled. Each caller
+// reserves its slot up front, so waiting doesn't hold the host's lock.
+func (c *Crawler) waitCrawlDelay(u *url.URL) {
+	if (!c.Config.RespectCrawlDelay && !c.Config.RespectRobots) || u.Host == "" {
+		return
+	}
+	p := c.pacer(u)
+	if p.rules.CrawlDelay <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	slot := time.Now()
+	if p.next.After(slot) {
+		slot = p.next
+	}
+	p.next = slot.Add(p.rules.CrawlDelay)
+	p.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(slot)):
+	case <-c.context().Done():
+	}
+}
+
+// addRobotsDisallowed records a URL skipped because robots.txt disallows it.
+func (c *Crawler) addRobotsDisallowed(u, foundOn string) {
+	c.filterOut(u, "disallowed by robots.txt")
+	c.resultsMu.Lock()
+	c.RobotsDisallowed = append(c.RobotsDisallowed, Result{
+		URL:          u,
+		FoundOn:      foundOn,
+		DiscoveredAt: time.Now(),
+	})
+	c.resultsMu.Unlock()
+	if c.Config.Verbose {
+		c.printf("[%s] %s: disallowed by robots.txt\n", color.YellowString("WRN"), u)
+	}
+}
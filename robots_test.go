@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitCrawlDelayCancelled(t *testing.T) {
+	c, err := New(Config{RespectCrawlDelay: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	u, _ := url.Parse("http://example.test/")
+	p := &hostPacer{rules: robotsRules{CrawlDelay: time.Minute}}
+	p.once.Do(func() {})
+	c.pacers.Store(u.Host, p)
+
+	// The first slot is free, the others queue a minute apart
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.waitCrawlDelay(u)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("waitCrawlDelay ignored the cancelled crawl")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Metadata describes a crawl run so exported artifacts are self-describing.
+type Metadata struct {
+	RunID      string    `json:"run_id"`
+	StartedAt  time.Time `json:"started_at"`
+	Version    string    `json:"version"`
+	ConfigHash string    `json:"config_hash"` // sha256 of the effective configuration
+}
+
+// newRunID returns a random RFC 4122 version 4 UUID.
+func newRunID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// configHash fingerprints the configuration, ignoring callbacks and
+// interfaces whose printed form is a memory address that changes every run.
+func configHash(cfg Config) string {
+	cfg.TokenRefresh = nil
+	cfg.OnLevelComplete = nil
+	cfg.RequestSigner = nil
+	cfg.Sinks = nil
+	cfg.Tracer = nil
+	sum := sha256.Sum256(fmt.Appendf(nil, "%+v", cfg))
+	return hex.EncodeToString(sum[:])
+}
+
+// initMetadata assigns the run its ID and records how it was started.
+func (c *Crawler) initMetadata() {
+	c.RunID = newRunID()
+	c.Metadata = Metadata{
+		RunID:      c.RunID,
+		StartedAt:  c.startedAt,
+		Version:    Version,
+		ConfigHash: configHash(c.Config),
+	}
+}
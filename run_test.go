@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestConfigHashStable(t *testing.T) {
+	// Built twice so every pointer differs between the two configs
+	build := func() Config {
+		return Config{
+			TargetURL:     "https://example.com",
+			MaxDepth:      3,
+			Headers:       map[string]string{"X-A": "1", "X-B": "2"},
+			Sinks:         []Sink{NewJSONSink(io.Discard)},
+			Tracer:        noop.NewTracerProvider().Tracer("test"),
+			RequestSigner: func(*http.Request) error { return nil },
+			TokenRefresh:  func() (string, error) { return "", nil },
+		}
+	}
+	a, b := build(), build()
+	if configHash(a) != configHash(b) {
+		t.Error("identical configs hash differently")
+	}
+	b.MaxDepth = 4
+	if configHash(a) == configHash(b) {
+		t.Error("different configs hash the same")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net"
+	"net/url"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ScopeMode values. Only the target's host is internal by default.
+const (
+	ScopeHost       = "host"
+	ScopeDomain     = "domain"     // Every host under the target's registered domain
+	ScopeSubdomains = "subdomains" // Alias of ScopeDomain
+)
+
+// registeredDomain returns the registered domain (eTLD+1) of host, e.g.
+// example.co.uk for www.example.co.uk, or host itself for IP addresses and
+// names without a public suffix such as localhost.
+func registeredDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// targetURL returns the parsed TargetURL. A target given without a scheme is
+// read as a bare host, so it can be compared before prepareTarget runs.
+func (c *Crawler) targetURL() *url.URL {
+	raw := c.Config.TargetURL
+	if !hasScheme(raw) {
+		raw = "//" + raw
+	}
+	target, err := url.Parse(raw)
+	if err != nil {
+		return &url.URL{}
+	}
+	return target
+}
+
+// isInternal reports whether u is in the target's scope, see ScopeMode.
+func (c *Crawler) isInternal(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	return c.inScope(parsed, c.targetURL())
+}
+
+// inTargets reports whether u is internal to the target or to one of the
+// MergedTargets, for reports covering merged crawls. Requests still go by
+// the target alone.
+func (c *Crawler) inTargets(u *url.URL) bool {
+	if c.inScope(u, c.targetURL()) {
+		return true
+	}
+	for _, t := range c.MergedTargets {
+		if target, err := url.Parse(t); err == nil && c.inScope(u, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// inScope reports whether u is internal relative to base under ScopeMode.
+func (c *Crawler) inScope(u, base *url.URL) bool {
+	if c.Config.ScopeMode == ScopeDomain {
+		return registeredDomain(asciiHost(u.Hostname())) == registeredDomain(asciiHost(base.Hostname()))
+	}
+	return hostKey(u) == hostKey(base)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Seed is an additional starting request. Unlike the target it may use any
+// method and carry a body, to reach pages only served in response to a POST.
+type Seed struct {
+	URL         string `json:"url"`
+	Method      string `json:"method,omitempty"`
+	Body        string `json:"body,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// LoadSeeds reads a JSON array of seeds from path.
+func LoadSeeds(path string) ([]Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var seeds []Seed
+	if err := json.Unmarshal(data, &seeds); err != nil {
+		return nil, fmt.Errorf("invalid seed file %s: %w", path, err)
+	}
+	return seeds, nil
+}
+
+// crawlSeed sends a seed request and processes its response like the
+// target page. Relative seed URLs are resolved against the target.
+func (c *Crawler) crawlSeed(seed Seed) {
+	method := strings.ToUpper(seed.Method)
+	if method == "" {
+		method = "GET"
+	}
+	base, err := url.Parse(c.Config.TargetURL)
+	if err != nil {
+		return
+	}
+	target, err := base.Parse(seed.URL)
+	if err != nil {
+		c.logf(color.RedString, "[ERR] Invalid seed URL %s: %v", seed.URL, err)
+		return
+	}
+	abs := normalizeURL(target)
+	if method == "GET" {
+		if _, loaded := c.Visited.LoadOrStore(abs, true); loaded {
+			return
+		}
+		c.filterOut(abs, "seed request")
+	}
+	if !c.robotsAllowed(target) {
+		c.addRobotsDisallowed(abs, "")
+		return
+	}
+
+	req, err := c.newRequest(method, abs)
+	if err != nil {
+		c.logf(color.RedString, "[ERR] Invalid seed %s %s: %v", method, abs, err)
+		return
+	}
+	if seed.Body != "" {
+		body := seed.Body
+		req.Body = io.NopCloser(strings.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(body)), nil
+		}
+		contentType := seed.ContentType
+		if contentType == "" {
+			contentType = "application/x-www-form-urlencoded"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	c.levelStart(0)
+	defer c.levelDone(0)
+	if err := c.crawlRequest(req, 0); err != nil && c.Config.Verbose {
+		c.printf("[%s] %s: %v\n", color.RedString("ERR"), abs, err)
+	}
+}
+
+// seedURL validates target as a link found on foundOn and records it, then
+// crawls it at depth when it is internal. source names the origin of the
+// URL, such as "template" or "sitemap", in discrepancy reasons. The
+// validation runs in the background, tracked by wg.
+func (c *Crawler) seedURL(wg *sync.WaitGroup, target *url.URL, foundOn, source string, depth int) {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return
+	}
+	abs := normalizeURL(target)
+	if !c.urlAllowed(abs) {
+		return
+	}
+	if _, loaded := c.Visited.LoadOrStore(abs, true); loaded {
+		return
+	}
+	if c.isBlockedHost(target.Hostname()) {
+		c.filterOut(abs, errBlockedHost.Error())
+		return
+	}
+	if !c.robotsAllowed(target) {
+		c.addRobotsDisallowed(abs, foundOn)
+		return
+	}
+	isExternal := !c.isInternal(abs)
+	if isExternal && c.Config.OnlyInternal {
+		// Not even probed, like external links found on pages
+		c.filterOut(abs, "external link excluded by OnlyInternal")
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if !c.acquire(c.validateSem) {
+			return
+		}
+		v := c.validateLink(abs)
+		<-c.validateSem
+		if !v.Valid {
+			c.filterOut(abs, fmt.Sprintf("%s URL returned status %d", source, v.Status))
+			return
+		}
+		li := v.link(abs, isExternal)
+		recurse := !isExternal && !c.stopped() && !c.hostExpired(target.Host)
+		switch {
+		case !isExternal && c.Config.OnlyExternal:
+			c.filterOut(abs, "internal link excluded by OnlyExternal")
+		case recurse:
+			c.holdResult(li, foundOn, 0)
+		default:
+			c.addResult(li, foundOn, 0)
+		}
+		if recurse {
+			c.schedule(abs, depth)
+		}
+	}()
+}
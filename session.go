@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultSessionIDPatterns match the session tokens of common platforms:
+// Java path parameters, ASP.NET cookieless segments and PHP, Java, ASP and
+// generic session query parameters.
+var DefaultSessionIDPatterns = []string{
+	`(?i);jsessionid=[^/?#]*`,
+	`/\([SAF]\([^)]*\)\)`,
+	`(?i)^(phpsessid|jsessionid|aspsessionid\w*|sessionid|session_id|sid)=`,
+}
+
+// stripSessionIDs removes session tokens from u before it is used as a
+// dedup key. A SessionIDPatterns match in the path is cut out; a query
+// parameter ("name=value") matching a pattern is dropped altogether.
+func (c *Crawler) stripSessionIDs(u *url.URL) *url.URL {
+	if len(c.sessionIDs) == 0 {
+		return u
+	}
+	n := *u
+	path := n.EscapedPath()
+	for _, re := range c.sessionIDs {
+		path = re.ReplaceAllString(path, "")
+	}
+	if path != n.EscapedPath() {
+		if decoded, err := url.PathUnescape(path); err == nil {
+			n.Path, n.RawPath = decoded, path
+		}
+	}
+
+	if n.RawQuery != "" {
+		params := strings.Split(n.RawQuery, "&")
+		kept := params[:0]
+		for _, p := range params {
+			if !matchesAny(c.sessionIDs, p) {
+				kept = append(kept, p)
+			}
+		}
+		n.RawQuery = strings.Join(kept, "&")
+	}
+	return &n
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
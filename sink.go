@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Sink receives every result as it is discovered. Write is called from
+// concurrent crawl goroutines and should not block for long; Close is
+// called once, after the last result.
+type Sink interface {
+	Write(r Result) error
+	Close() error
+}
+
+// openSinks sets up the outputs results are sent to: the console, the
+// webhook when configured, then the sinks attached to Config.
+func (c *Crawler) openSinks() {
+	c.sinks = []Sink{consoleSink{c}}
+	if c.Config.WebhookURL != "" {
+		c.sinks = append(c.sinks, newWebhookSink(c))
+	}
+	c.sinks = append(c.sinks, c.Config.Sinks...)
+}
+
+func (c *Crawler) closeSinks() {
+	for _, s := range c.sinks {
+		if err := s.Close(); err != nil {
+			c.logf(color.RedString, "[ERR] Closing output: %v", err)
+		}
+	}
+	c.sinks = nil
+}
+
+// emit sends r to every sink.
+func (c *Crawler) emit(r Result) {
+	for _, s := range c.sinks {
+		if err := s.Write(r); err != nil && c.Config.Verbose {
+			c.printf("[%s] output %s: %v\n", color.RedString("ERR"), r.URL, err)
+		}
+	}
+}
+
+// consoleSink prints results as [INT], [PAG] and [EXT] lines, and links
+// answered with a redirect as [RDR] lines showing their target.
+type consoleSink struct {
+	c *Crawler
+}
+
+func (s consoleSink) Write(r Result) error {
+	if r.Redirect != 0 {
+		s.c.printf("[%s] %s -> %s (%d)\n", color.YellowString("RDR"), s.c.formatResult(r.URL), r.Location, r.Redirect)
+	} else if r.External {
+		s.c.printf("[%s] %s\n", color.CyanString("EXT"), r.URL)
+	} else if r.Pagination {
+		s.c.printf("[%s] %s\n", color.GreenString("PAG"), s.c.formatResult(r.URL))
+	} else {
+		s.c.printf("[%s] %s\n", color.GreenString("INT"), s.c.formatResult(r.URL))
+	}
+	return nil
+}
+
+func (s consoleSink) Close() error { return nil }
+
+// JSONSink writes each result as a line of JSON.
+type JSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink returns a Sink writing JSON Lines to w. Closing the sink does
+// not close w.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{enc: json.NewEncoder(w)}
+}
+
+func (s *JSONSink) Write(r Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(r)
+}
+
+func (s *JSONSink) Close() error { return nil }
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// maxSitemaps bounds how many child sitemaps of an index are fetched.
+const maxSitemaps = 50
+
+// sitemapDoc covers both <urlset> and <sitemapindex> documents.
+type sitemapDoc struct {
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// SitemapCoverage reports how many sitemap URLs the crawl reached.
+type SitemapCoverage struct {
+	Total   int      `json:"total"`
+	Found   int      `json:"found"`
+	Percent float64  `json:"percent"`
+	Missing []string `json:"missing"`
+}
+
+// loadSitemap reads the sitemap at location, a URL or a local file,
+// following sitemap indexes one level deep. The URLs are returned
+// normalized and deduplicated, in document order.
+func (c *Crawler) loadSitemap(location string) ([]string, error) {
+	doc, err := c.readSitemap(location)
+	if err != nil {
+		return nil, err
+	}
+	for i, sm := range doc.Sitemaps {
+		if i >= maxSitemaps {
+			break
+		}
+		child, err := c.readSitemap(strings.TrimSpace(sm.Loc))
+		if err != nil {
+			if c.Config.Verbose {
+				c.printf("[%s] %s: %v\n", color.RedString("ERR"), sm.Loc, err)
+			}
+			continue
+		}
+		doc.URLs = append(doc.URLs, child.URLs...)
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, loc := range doc.URLs {
+		u, err := url.Parse(strings.TrimSpace(loc.Loc))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		norm := normalizeURL(u)
+		if !seen[norm] {
+			seen[norm] = true
+			urls = append(urls, norm)
+		}
+	}
+	return urls, nil
+}
+
+func (c *Crawler) readSitemap(location string) (*sitemapDoc, error) {
+	var r io.Reader
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		req, err := c.newRequest("GET", location)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.do(c.Client, req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("sitemap returned %s", resp.Status)
+		}
+		r = resp.Body
+	} else {
+		file, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	// sitemap.xml.gz is usually served as a gzip file, not gzip-encoded
+	r, err := decodeBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sitemap %s: %w", location, err)
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(io.LimitReader(r, maxBodySize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid sitemap %s: %w", location, err)
+	}
+	return &doc, nil
+}
+
+// seedFromSitemap crawls the URLs of the target's sitemap as depth-0 seeds,
+// so pages nothing links to are found too. The sitemap is Config.Sitemap
+// when set, else /sitemap.xml then /sitemap.xml.gz on the target; a site
+// without one is crawled as usual.
+func (c *Crawler) seedFromSitemap() {
+	base, err := url.Parse(c.Config.TargetURL)
+	if err != nil {
+		return
+	}
+	locations := []string{c.Config.Sitemap}
+	if c.Config.Sitemap == "" {
+		locations = []string{base.JoinPath("/sitemap.xml").String(), base.JoinPath("/sitemap.xml.gz").String()}
+	}
+
+	for _, location := range locations {
+		urls, err := c.loadSitemap(location)
+		if err != nil {
+			if c.Config.Verbose {
+				c.printf("[%s] %s: %v\n", color.YellowString("WRN"), location, err)
+			}
+			continue
+		}
+		var wg sync.WaitGroup
+		for _, raw := range urls {
+			if target, err := url.Parse(raw); err == nil {
+				c.seedURL(&wg, target, location, "sitemap", 0)
+			}
+		}
+		wg.Wait()
+		return
+	}
+}
+
+// SitemapCoverage compares the sitemap URLs against the URLs the crawl
+// visited. It returns nil when no sitemap was loaded.
+func (c *Crawler) SitemapCoverage() *SitemapCoverage {
+	if len(c.sitemapURLs) == 0 {
+		return nil
+	}
+	cov := &SitemapCoverage{Total: len(c.sitemapURLs), Missing: []string{}}
+	for _, u := range c.sitemapURLs {
+		if _, ok := c.Visited.Load(u); ok {
+			cov.Found++
+		} else {
+			cov.Missing = append(cov.Missing, u)
+		}
+	}
+	cov.Percent = float64(cov.Found) * 100 / float64(cov.Total)
+	return cov
+}
+
+// PrintSitemapCoverage outputs the coverage summary and the missed URLs.
+func (c *Crawler) PrintSitemapCoverage() {
+	cov := c.SitemapCoverage()
+	if cov == nil {
+		return
+	}
+	fmt.Fprintf(c.console(), "\n%s\n", color.MagentaString("=== Sitemap Coverage ==="))
+	fmt.Fprintf(c.console(), "%d/%d URLs reached (%.1f%%)\n", cov.Found, cov.Total, cov.Percent)
+	for _, u := range cov.Missing {
+		fmt.Fprintf(c.console(), "[%s] %s\n", color.YellowString("MISS"), c.formatResult(u))
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// SaveSplit writes the findings to OutputDir, one JSON array per category:
+// internal.json, external.json, emails.json and forms.json, plus
+// sensitive.json and websockets.json when any were found.
+func (c *Crawler) SaveSplit() error {
+	if c.Config.OutputDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.Config.OutputDir, 0o755); err != nil {
+		return err
+	}
+
+	internal, external := []Result{}, []Result{}
+	for _, r := range c.Results {
+		r.URL = c.formatResult(r.URL)
+		r.FoundOn = c.formatResult(r.FoundOn)
+		if r.External {
+			external = append(external, r)
+		} else {
+			internal = append(internal, r)
+		}
+	}
+	emails := append([]Result{}, c.Emails...)
+	forms := append([]Form{}, c.Forms...)
+	sensitive, websockets := c.Sensitive, c.WebSockets
+	if c.Config.Canonical {
+		internal = canonicalResults(internal)
+		external = canonicalResults(external)
+		emails = canonicalResults(emails)
+		forms = canonicalForms(forms)
+		sensitive = canonicalResults(sensitive)
+		websockets = canonicalResults(websockets)
+	}
+
+	files := map[string]any{
+		"internal.json": internal,
+		"external.json": external,
+		"emails.json":   emails,
+		"forms.json":    forms,
+	}
+	if len(sensitive) > 0 {
+		files["sensitive.json"] = sensitive
+	}
+	if len(websockets) > 0 {
+		files["websockets.json"] = websockets
+	}
+	for name, v := range files {
+		if err := writeJSONFile(filepath.Join(c.Config.OutputDir, name), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSONFile(path string, v any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
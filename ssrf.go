@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// errBlockedHost is returned for requests to a host resolving to a private
+// address under BlockPrivateIPs.
+var errBlockedHost = errors.New("private address blocked")
+
+// cgnatRange is the shared address space (RFC 6598), not covered by net.IP.IsPrivate.
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPrivateIP reports whether ip is loopback, private, link-local or otherwise
+// not a public unicast address.
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || cgnatRange.Contains(ip)
+}
+
+// newDialer returns the dialer used by the transport. With BlockPrivateIPs it
+// refuses to connect to private addresses, which also covers DNS rebinding
+// and redirects that the pre-request checks cannot see. Through a proxy the
+// dialer only reaches the proxy, so targets rely on the check in send.
+func newDialer(cfg Config) *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if cfg.BlockPrivateIPs && cfg.ProxyURL == "" {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+				return fmt.Errorf("blocked private address %s", ip)
+			}
+			return nil
+		}
+	}
+	return dialer
+}
+
+// isBlockedHost resolves host and reports whether any of its addresses is
+// private. Lookups are cached per host for the duration of the crawl.
+func (c *Crawler) isBlockedHost(host string) bool {
+	if !c.Config.BlockPrivateIPs {
+		return false
+	}
+	if cached, ok := c.hostBlocked.Load(host); ok {
+		return cached.(bool)
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		resolved, err := net.LookupIP(host)
+		if err != nil {
+			c.hostBlocked.Store(host, false)
+			return false // The request itself will fail and be reported
+		}
+		ips = resolved
+	}
+
+	blocked := false
+	for _, ip := range ips {
+		if isPrivateIP(ip) {
+			blocked = true
+			break
+		}
+	}
+	c.hostBlocked.Store(host, blocked)
+	return blocked
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// DirStats summarizes the internal results found under a top-level directory.
+type DirStats struct {
+	Directory string `json:"directory"`
+	URLs      int    `json:"urls"`
+	Statuses  []int  `json:"statuses"`
+	WithQuery int    `json:"with_query"`
+}
+
+// directoryStats groups internal results by their first path segment.
+// Files directly under the root are grouped under "/".
+func (c *Crawler) directoryStats() []DirStats {
+	byDir := make(map[string]*DirStats)
+	statuses := make(map[string]map[int]bool)
+
+	for _, r := range c.Results {
+		u, err := url.Parse(r.URL)
+		if err != nil || !c.inTargets(u) {
+			continue
+		}
+
+		dir := "/"
+		parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+		if len(parts) > 1 {
+			dir = "/" + parts[0] + "/"
+		}
+
+		ds, ok := byDir[dir]
+		if !ok {
+			ds = &DirStats{Directory: dir}
+			byDir[dir] = ds
+			statuses[dir] = make(map[int]bool)
+		}
+		ds.URLs++
+		if u.RawQuery != "" {
+			ds.WithQuery++
+		}
+		if r.Status != 0 && !statuses[dir][r.Status] {
+			statuses[dir][r.Status] = true
+			ds.Statuses = append(ds.Statuses, r.Status)
+		}
+	}
+
+	stats := make([]DirStats, 0, len(byDir))
+	for _, ds := range byDir {
+		sort.Ints(ds.Statuses)
+		stats = append(stats, *ds)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Directory < stats[j].Directory
+	})
+	return stats
+}
+
+// PrintDirStats outputs the per-directory summary to the console.
+func (c *Crawler) PrintDirStats() {
+	if !c.Config.DirStats {
+		return
+	}
+	fmt.Fprintf(c.console(), "\n%s\n", color.MagentaString("=== Directories ==="))
+	for _, ds := range c.directoryStats() {
+		fmt.Fprintf(c.console(), "%-30s urls=%d statuses=%v with_query=%d\n", ds.Directory, ds.URLs, ds.Statuses, ds.WithQuery)
+	}
+}
+
+// allowedTLD reports whether an external host falls under one of
+// ExternalTLDs, matched on whole labels so "uk" covers "example.co.uk" and
+// "amazonaws.com" covers "s3.amazonaws.com". Every host passes when the
+// list is empty.
+func (c *Crawler) allowedTLD(host string) bool {
+	if len(c.Config.ExternalTLDs) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, tld := range c.Config.ExternalTLDs {
+		tld = strings.ToLower(strings.Trim(tld, "."))
+		if host == tld || strings.HasSuffix(host, "."+tld) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExternalDomains returns the sorted, deduplicated hosts of external results.
+func (c *Crawler) ExternalDomains() []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, r := range c.Results {
+		u, err := url.Parse(r.URL)
+		if err != nil || c.inTargets(u) {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != "" && !seen[host] {
+			seen[host] = true
+			domains = append(domains, host)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// Parameters returns the sorted names of every query parameter seen on
+// internal results, along with the endpoints (URL without query) using each.
+func (c *Crawler) Parameters() ([]string, map[string][]string) {
+	endpoints := make(map[string][]string)
+	for _, r := range c.Results {
+		u, err := url.Parse(r.URL)
+		if err != nil || !c.inTargets(u) || u.RawQuery == "" {
+			continue
+		}
+		endpoint := *u
+		endpoint.RawQuery = ""
+		endpoint.Fragment = ""
+		for name := range u.Query() {
+			if !slices.Contains(endpoints[name], endpoint.String()) {
+				endpoints[name] = append(endpoints[name], endpoint.String())
+			}
+		}
+	}
+
+	names := make([]string, 0, len(endpoints))
+	for name := range endpoints {
+		names = append(names, name)
+		sort.Strings(endpoints[name])
+	}
+	sort.Strings(names)
+	return names, endpoints
+}
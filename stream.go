@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// waveRecord is one JSON Lines entry of the stream. Waves are numbered by
+// crawl depth and written in increasing order, each once complete.
+type waveRecord struct {
+	Wave int `json:"wave"`
+	Result
+}
+
+// openStream creates StreamPath, "-" meaning stdout, in which case the
+// caller should point Config.Console elsewhere. The returned function flushes
+// and closes it.
+func (c *Crawler) openStream() (func(), error) {
+	file := os.Stdout
+	if c.Config.StreamPath != "-" {
+		f, err := os.Create(c.Config.StreamPath)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+	c.stream = bufio.NewWriter(file)
+	return func() {
+		c.levelMu.Lock()
+		c.stream.Flush()
+		c.stream = nil
+		c.levelMu.Unlock()
+		if file != os.Stdout {
+			file.Close()
+		}
+	}, nil
+}
+
+// streamWave writes the results of a completed depth to the stream. The
+// caller holds levelMu.
+func (c *Crawler) streamWave(wave int, results []Result) {
+	if c.stream == nil {
+		return
+	}
+	encoder := json.NewEncoder(c.stream)
+	for _, r := range results {
+		r.URL = c.formatResult(r.URL)
+		r.FoundOn = c.formatResult(r.FoundOn)
+		encoder.Encode(waveRecord{Wave: wave, Result: r})
+	}
+	c.stream.Flush()
+}
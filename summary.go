@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Crawl outcomes reported by Summary.Status.
+const (
+	OutcomeSuccess = "success"
+	OutcomePartial = "partial" // Stopped early or capped, some of the site may be missing
+	OutcomeFailed  = "failed"
+)
+
+// Process exit codes matching the outcomes. 2 is left to flag parsing errors.
+const (
+	exitSuccess = 0
+	exitFailed  = 1
+	exitPartial = 3
+)
+
+// Summary is the outcome of a crawl, returned by Start.
+type Summary struct {
+	Status     string   `json:"status"`
+	Reasons    []string `json:"reasons,omitempty"`
+	Target     string   `json:"target"`
+	RunID      string   `json:"run_id"`
+	Pages      int64    `json:"pages"`
+	Results    int      `json:"results"`
+	Requests   int64    `json:"requests"`
+	Errors     int64    `json:"errors"` // Requests without response, 429 or 5xx
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// ExitCode maps the outcome to the process exit code.
+func (s *Summary) ExitCode() int {
+	switch s.Status {
+	case OutcomeSuccess:
+		return exitSuccess
+	case OutcomePartial:
+		return exitPartial
+	}
+	return exitFailed
+}
+
+// summarize builds the Summary of a crawl that ended with err. A crawl fails
+// when Start returned an error, no page could be fetched or most requests
+// failed; it is partial when it was halted or capped.
+func (c *Crawler) summarize(err error) *Summary {
+	c.resultsMu.Lock()
+	results := len(c.Results)
+	capped := len(c.TimeCapped)
+	c.resultsMu.Unlock()
+
+	s := &Summary{
+		Status:     OutcomeSuccess,
+		Target:     c.Config.TargetURL,
+		RunID:      c.RunID,
+		Pages:      c.pagesFetched.Load(),
+		Results:    results,
+		Requests:   c.requests.Load(),
+		Errors:     c.requestErrors.Load(),
+		DurationMs: time.Since(c.startedAt).Milliseconds(),
+	}
+
+	switch {
+	case err != nil:
+		s.Status = OutcomeFailed
+		s.Reasons = append(s.Reasons, err.Error())
+	case s.Pages == 0 && !c.Config.Estimate:
+		s.Status = OutcomeFailed
+		s.Reasons = append(s.Reasons, "no page could be fetched")
+	case s.Requests > 0 && s.Errors*2 > s.Requests:
+		s.Status = OutcomeFailed
+		s.Reasons = append(s.Reasons, fmt.Sprintf("%d of %d requests failed", s.Errors, s.Requests))
+	}
+	if s.Status == OutcomeFailed {
+		return s
+	}
+
+	if reason, ok := c.haltReason.Load().(string); ok && c.stopped() {
+		s.Reasons = append(s.Reasons, "halted: "+reason)
+	}
+	if capped > 0 {
+		s.Reasons = append(s.Reasons, fmt.Sprintf("%d host(s) reached their time budget", capped))
+	}
+	if c.paginated.Load() >= maxPaginationPages {
+		s.Reasons = append(s.Reasons, "pagination limit reached")
+	}
+	if len(s.Reasons) > 0 {
+		s.Status = OutcomePartial
+	}
+	return s
+}
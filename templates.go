@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// maxTemplateURLs bounds the URLs a single template expands to, since each
+// placeholder multiplies the count.
+const maxTemplateURLs = 100000
+
+var templatePlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// ParseTemplateValues parses "name=v1,v2" or "name=@file", the file holding
+// one value per line, into the values substituted for {name}.
+func ParseTemplateValues(s string) (string, []string, error) {
+	name, list, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("invalid template values %q, expected name=v1,v2 or name=@file", s)
+	}
+	if path, ok := strings.CutPrefix(list, "@"); ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		var values []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if v := strings.TrimSpace(scanner.Text()); v != "" {
+				values = append(values, v)
+			}
+		}
+		return name, values, scanner.Err()
+	}
+	return name, strings.Split(list, ","), nil
+}
+
+// ExpandTemplate returns every URL obtained by substituting the values of
+// each placeholder of tmpl, e.g. /users/{id}. Values are escaped for the
+// part of the URL, path or query, they end up in.
+func ExpandTemplate(tmpl string, values map[string][]string) ([]string, error) {
+	urls := []string{""}
+	rest := tmpl
+	for {
+		loc := templatePlaceholder.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			break
+		}
+		name := rest[loc[2]:loc[3]]
+		vals := values[name]
+		if len(vals) == 0 {
+			return nil, fmt.Errorf("template %s: no values for {%s}", tmpl, name)
+		}
+		if len(urls)*len(vals) > maxTemplateURLs {
+			return nil, fmt.Errorf("template %s expands to more than %d URLs", tmpl, maxTemplateURLs)
+		}
+		inQuery := strings.Contains(tmpl[:len(tmpl)-len(rest)+loc[0]], "?")
+
+		expanded := make([]string, 0, len(urls)*len(vals))
+		for _, prefix := range urls {
+			for _, v := range vals {
+				escaped := url.PathEscape(v)
+				if inQuery {
+					escaped = url.QueryEscape(v)
+				}
+				expanded = append(expanded, prefix+rest[:loc[0]]+escaped)
+			}
+		}
+		urls = expanded
+		rest = rest[loc[1]:]
+	}
+	for i := range urls {
+		urls[i] += rest
+	}
+	return urls, nil
+}
+
+// crawlTemplates validates the URLs expanded from the configured templates
+// as if they were linked from the target: valid ones become results and
+// internal ones are crawled.
+func (c *Crawler) crawlTemplates() {
+	base, err := url.Parse(c.Config.TargetURL)
+	if err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, tmpl := range c.Config.Templates {
+		urls, err := ExpandTemplate(tmpl, c.Config.TemplateValues)
+		if err != nil {
+			c.logf(color.RedString, "[ERR] %v", err)
+			continue
+		}
+		for _, raw := range urls {
+			if target, err := base.Parse(raw); err == nil {
+				c.seedURL(&wg, target, c.Config.TargetURL, "template", 1)
+			}
+		}
+	}
+	wg.Wait()
+}
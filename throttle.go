@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// rampAcquire reserves an in-flight request slot during the RampUp window.
+// The allowance grows linearly from a single request up to the worker count,
+// so the crawl starts gently instead of opening at full concurrency.
+// The returned function releases the slot. A cancelled crawl stops waiting.
+func (c *Crawler) rampAcquire() func() {
+	if c.Config.RampUp <= 0 {
+		return func() {}
+	}
+	for {
+		elapsed := time.Since(c.startedAt)
+		if elapsed >= c.Config.RampUp {
+			return func() {}
+		}
+		workers := cap(c.semaphore) + cap(c.validateSem)
+		allowed := 1 + int(float64(workers-1)*float64(elapsed)/float64(c.Config.RampUp))
+
+		c.rampMu.Lock()
+		if c.inFlight < allowed {
+			c.inFlight++
+			c.rampMu.Unlock()
+			return func() {
+				c.rampMu.Lock()
+				c.inFlight--
+				c.rampMu.Unlock()
+			}
+		}
+		c.rampMu.Unlock()
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-c.context().Done():
+			return func() {}
+		}
+	}
+}
+
+// bandwidthChunk bounds a single throttled read so pacing stays smooth.
+const bandwidthChunk = 32 << 10
+
+// bandwidthLimiter paces body reads across all requests to a byte rate.
+// Each read reserves the next slot on a shared schedule and sleeps until it.
+type bandwidthLimiter struct {
+	mu   sync.Mutex
+	rate float64 // Bytes per second
+	next time.Time
+}
+
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	l.mu.Unlock()
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+	}
+}
+
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	lim *bandwidthLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunk {
+		p = p[:bandwidthChunk]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.lim.wait(t.ctx, n)
+	}
+	return n, err
+}
+
+// throttle limits r to BandwidthLimit, shared with every other body read.
+func (c *Crawler) throttle(r io.Reader) io.Reader {
+	if c.bandwidth == nil {
+		return r
+	}
+	return &throttledReader{ctx: c.context(), r: r, lim: c.bandwidth}
+}
+
+// adaptiveStart is the concurrency an AdaptiveConcurrency crawl opens with.
+const adaptiveStart = 2
+
+// adaptiveLimiter tunes the number of in-flight requests AIMD-style: the
+// limit grows by one for every limit's worth of successful requests and is
+// halved on errors, timeouts, 429 and 5xx, at most once per second so a
+// burst of failures counts as one.
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    float64
+	max      float64
+	inFlight int
+	lastCut  time.Time
+}
+
+func newAdaptiveLimiter(max int) *adaptiveLimiter {
+	a := &adaptiveLimiter{limit: min(adaptiveStart, float64(max)), max: float64(max)}
+	a.cond = sync.NewCond(&a.mu)
+	return a
+}
+
+// acquire waits for a free slot. Once ctx is done it returns at once, the
+// request then fails on its own; wake lets waiters see the cancellation.
+func (a *adaptiveLimiter) acquire(ctx context.Context) {
+	a.mu.Lock()
+	for a.inFlight >= int(a.limit) && ctx.Err() == nil {
+		a.cond.Wait()
+	}
+	a.inFlight++
+	a.mu.Unlock()
+}
+
+// wake rechecks every waiting acquire.
+func (a *adaptiveLimiter) wake() {
+	a.mu.Lock()
+	a.cond.Broadcast()
+	a.mu.Unlock()
+}
+
+// release frees a slot and adjusts the limit. It reports the new limit when
+// it was cut.
+func (a *adaptiveLimiter) release(failed bool) (int, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inFlight--
+	defer a.cond.Broadcast()
+
+	if !failed {
+		a.limit = min(a.max, a.limit+1/a.limit)
+		return 0, false
+	}
+	if time.Since(a.lastCut) < time.Second {
+		return 0, false
+	}
+	a.lastCut = time.Now()
+	a.limit = max(1, a.limit/2)
+	return int(a.limit), true
+}
+
+// adaptiveAcquire waits for a request slot under AdaptiveConcurrency. The
+// returned function releases it given the outcome of the request.
+func (c *Crawler) adaptiveAcquire() func(*http.Response, error) {
+	if c.adaptive == nil {
+		return func(*http.Response, error) {}
+	}
+	c.adaptive.acquire(c.context())
+	return func(resp *http.Response, err error) {
+		if limit, cut := c.adaptive.release(requestFailed(resp, err)); cut && c.Config.Verbose {
+			c.printf("[%s] errors rising, backing off to %d concurrent requests\n", color.YellowString("WRN"), limit)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// returnsOnCancel fails the test unless wait returns soon after the crawl
+// of c is cancelled.
+func returnsOnCancel(t *testing.T, c *Crawler, wait func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	stop := context.AfterFunc(ctx, func() { c.halt("interrupted") })
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("still waiting after the crawl was cancelled")
+	}
+}
+
+func TestWaitsCancelled(t *testing.T) {
+	now := time.Now()
+	sinceMidnight := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+	closed := TimeWindow{Start: (sinceMidnight + 2*time.Hour) % (24 * time.Hour), End: (sinceMidnight + 3*time.Hour) % (24 * time.Hour)}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		wait func(c *Crawler)
+	}{
+		{"crawl window", Config{CrawlWindow: closed}, func(c *Crawler) { c.waitWindow() }},
+		{"ramp up", Config{RampUp: time.Hour}, func(c *Crawler) {
+			c.startedAt = time.Now()
+			c.inFlight = 1
+			c.rampAcquire()()
+		}},
+		{"adaptive concurrency", Config{AdaptiveConcurrency: true}, func(c *Crawler) {
+			c.adaptive.inFlight = adaptiveStart
+			c.adaptive.acquire(c.context())
+		}},
+		{"bandwidth", Config{BandwidthLimit: 1}, func(c *Crawler) {
+			c.bandwidth.wait(c.context(), 1)
+			c.bandwidth.wait(c.context(), 1<<20)
+			c.bandwidth.wait(c.context(), 1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			returnsOnCancel(t, c, func() { tt.wait(c) })
+		})
+	}
+}
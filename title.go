@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// titleProbeSize bounds the body read when validating with GET; titles sit
+// in the head, well within the first kilobytes of a page.
+const titleProbeSize = 64 << 10
+
+// PageTitle returns the text of the first <title> element of an HTML
+// document, whitespace collapsed, or "" when there is none.
+func PageTitle(content string) string {
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if string(name) != "title" {
+				continue
+			}
+			var b strings.Builder
+			for {
+				tt := z.Next()
+				if tt == html.TextToken {
+					b.Write(z.Text())
+					continue
+				}
+				if tt == html.ErrorToken && z.Err() != io.EOF {
+					return ""
+				}
+				return strings.Join(strings.Fields(b.String()), " ")
+			}
+		}
+	}
+}
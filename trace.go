@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HAR 1.2 subset, enough for HAR viewers to load the crawler's activity.
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	QueryString []harHeader `json:"queryString"`
+	Cookies     []harHeader `json:"cookies"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	Cookies     []harHeader `json:"cookies"`
+	Content     harContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// credentialHeaders are redacted from the trace unless TraceSecrets is set,
+// along with the custom Config.Headers, so the file can be shared.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Amz-Security-Token"}
+
+func (c *Crawler) harHeaders(h http.Header) []harHeader {
+	headers := []harHeader{}
+	for name, values := range h {
+		secret := !c.Config.TraceSecrets && c.isSecretHeader(name)
+		for _, v := range values {
+			if secret {
+				v = "[redacted]"
+			}
+			headers = append(headers, harHeader{Name: name, Value: v})
+		}
+	}
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Name < headers[j].Name
+	})
+	return headers
+}
+
+func (c *Crawler) isSecretHeader(name string) bool {
+	for _, h := range credentialHeaders {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	for h := range c.Config.Headers {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordTrace appends a HAR entry for req, preceded by one per redirect hop
+// up to TraceMaxRedirects. A nil resp records a failed request.
+func (c *Crawler) recordTrace(req *http.Request, resp *http.Response, started time.Time, reqErr error) {
+	if c.Config.TracePath == "" {
+		return
+	}
+	elapsed := float64(time.Since(started).Microseconds()) / 1000
+
+	// Redirect responses are chained from the final one, oldest last
+	var hops []*http.Response
+	if resp != nil {
+		for r := resp.Request.Response; r != nil; r = r.Request.Response {
+			hops = append(hops, r)
+		}
+		slices.Reverse(hops)
+	}
+	omitted := 0
+	if limit := c.Config.TraceMaxRedirects; limit > 0 && len(hops) > limit {
+		omitted = len(hops) - limit
+		hops = hops[:limit]
+	}
+
+	entries := make([]harEntry, 0, len(hops)+1)
+	for _, hop := range hops {
+		entries = append(entries, c.newHAREntry(hop.Request, hop, started, 0))
+	}
+	final := req
+	if resp != nil && len(entries) > 0 {
+		final = resp.Request
+	}
+	entry := c.newHAREntry(final, resp, started, elapsed)
+	if reqErr != nil {
+		entry.Comment = reqErr.Error()
+	} else if omitted > 0 {
+		entry.Comment = fmt.Sprintf("%d redirects omitted", omitted)
+	}
+	entries = append(entries, entry)
+
+	c.traceMu.Lock()
+	c.trace = append(c.trace, entries...)
+	c.traceMu.Unlock()
+}
+
+func (c *Crawler) newHAREntry(req *http.Request, resp *http.Response, started time.Time, elapsed float64) harEntry {
+	query := []harHeader{}
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			query = append(query, harHeader{Name: name, Value: v})
+		}
+	}
+
+	entry := harEntry{
+		StartedDateTime: started,
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     c.harHeaders(req.Header),
+			QueryString: query,
+			Cookies:     []harHeader{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: harResponse{
+			Headers:     []harHeader{},
+			Cookies:     []harHeader{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: harTimings{Send: 0, Wait: elapsed, Receive: 0},
+	}
+	if resp != nil {
+		entry.Response.Status = resp.StatusCode
+		entry.Response.StatusText = http.StatusText(resp.StatusCode)
+		entry.Response.HTTPVersion = resp.Proto
+		entry.Response.Headers = c.harHeaders(resp.Header)
+		entry.Response.Content = harContent{
+			Size:     resp.ContentLength,
+			MimeType: resp.Header.Get("Content-Type"),
+		}
+		entry.Response.RedirectURL = resp.Header.Get("Location")
+		entry.Response.BodySize = int(resp.ContentLength)
+	}
+	return entry
+}
+
+// SaveTrace writes every recorded request to TracePath as a HAR file.
+func (c *Crawler) SaveTrace() error {
+	if c.Config.TracePath == "" {
+		return nil
+	}
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{Name: "yg-scovery", Version: Version}
+
+	c.traceMu.Lock()
+	har.Log.Entries = append([]harEntry{}, c.trace...)
+	c.traceMu.Unlock()
+
+	file, err := os.Create(c.Config.TracePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(har)
+}
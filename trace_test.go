@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The token is only fetched on a 401
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<p>ok</p>`)
+	}))
+	defer srv.Close()
+
+	rule, err := ParseCookieRule("session=cookie-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{
+		TargetURL:    srv.URL,
+		TracePath:    t.TempDir() + "/trace.har",
+		TokenRefresh: func() (string, error) { return "bearer-secret", nil },
+		Cookies:      []CookieRule{rule},
+		Headers:      map[string]string{"X-Api-Key": "key-secret"},
+	}
+	for _, keep := range []bool{false, true} {
+		t.Run(fmt.Sprintf("TraceSecrets=%v", keep), func(t *testing.T) {
+			cfg.TraceSecrets = keep
+			c := crawlTest(t, cfg)
+			found := make(map[string]bool)
+			for _, e := range c.trace {
+				for _, h := range append(e.Request.Headers, e.Response.Headers...) {
+					found[h.Value] = true
+				}
+			}
+			for _, secret := range []string{"Bearer bearer-secret", "session=cookie-secret", "session=server-secret", "key-secret"} {
+				if found[secret] != keep {
+					t.Errorf("%q in trace = %v, want %v", secret, found[secret], keep)
+				}
+			}
+			if !keep && !found["[redacted]"] {
+				t.Error("no redacted header in the trace")
+			}
+		})
+	}
+}
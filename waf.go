@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ProtectedHost is a host that answered with a bot-protection interstitial
+// instead of its content.
+type ProtectedHost struct {
+	Host   string `json:"host"`
+	Vendor string `json:"vendor"`
+	URL    string `json:"url"` // First page the challenge was served on
+}
+
+// wafProbeSize is how much of an error page is read to look for a challenge.
+const wafProbeSize = 64 << 10
+
+// wafSignatures are body markers only found on challenge pages.
+var wafSignatures = []struct {
+	vendor string
+	marker []byte
+}{
+	{"Cloudflare", []byte("cf-browser-verification")},
+	{"Cloudflare", []byte("cf_chl_opt")},
+	{"Cloudflare", []byte("Attention Required! | Cloudflare")},
+	{"Akamai", []byte("Reference&#32;&#35;")},
+	{"Imperva", []byte("_Incapsula_Resource")},
+	{"Imperva", []byte("Incapsula incident ID")},
+	{"Sucuri", []byte("Sucuri WebSite Firewall")},
+	{"AWS WAF", []byte("awswaf.com")},
+	{"DataDome", []byte("captcha-delivery.com")},
+	{"PerimeterX", []byte("px-captcha")},
+}
+
+// detectWAF returns the vendor of the bot protection that served resp, or ""
+// for a regular page. Vendor headers alone are not enough since protected
+// sites send them on every response; they only count on a blocking status.
+func detectWAF(resp *http.Response, body []byte) string {
+	h := resp.Header
+	if h.Get("Cf-Mitigated") == "challenge" {
+		return "Cloudflare"
+	}
+	for _, sig := range wafSignatures {
+		if bytes.Contains(body, sig.marker) {
+			return sig.vendor
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+	default:
+		return ""
+	}
+	switch {
+	case h.Get("Cf-Ray") != "":
+		return "Cloudflare"
+	case strings.Contains(strings.ToLower(h.Get("Server")), "akamaighost"):
+		return "Akamai"
+	case h.Get("X-Iinfo") != "":
+		return "Imperva"
+	case h.Get("X-Sucuri-Id") != "":
+		return "Sucuri"
+	case h.Get("X-Datadome") != "":
+		return "DataDome"
+	}
+	return ""
+}
+
+// addProtectedHost records the first challenge page served by a host.
+func (c *Crawler) addProtectedHost(host, vendor, page string) {
+	if _, loaded := c.protected.LoadOrStore(host, true); loaded {
+		return
+	}
+	c.printf("[%s] %s (%s)\n", color.RedString("WAF"), host, vendor)
+	c.resultsMu.Lock()
+	c.Protected = append(c.Protected, ProtectedHost{Host: host, Vendor: vendor, URL: page})
+	c.resultsMu.Unlock()
+}
+
+// isProtected reports whether pages of host should be skipped because it
+// was found behind bot protection.
+func (c *Crawler) isProtected(host string) bool {
+	if !c.Config.SkipProtectedHosts {
+		return false
+	}
+	_, ok := c.protected.Load(host)
+	return ok
+}
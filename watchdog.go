@@ -0,0 +1,55 @@
+package main
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// halt stops the crawl from scheduling any further work. Pages and
+// validations already in flight are left to finish.
+func (c *Crawler) halt(reason string) {
+	if c.halted.CompareAndSwap(false, true) {
+		c.haltReason.Store(reason)
+		c.logf(color.YellowString, "[WRN] Stopping crawl: %s", reason)
+	}
+	// Cancellation halts the crawl, requests waiting for a slot must notice
+	if c.adaptive != nil {
+		c.adaptive.wake()
+	}
+}
+
+func (c *Crawler) stopped() bool {
+	return c.halted.Load()
+}
+
+// watchIdle halts the crawl once no new result has been added for
+// IdleTimeout. It returns when done is closed.
+func (c *Crawler) watchIdle(done <-chan struct{}) {
+	interval := c.Config.IdleTimeout / 4
+	if interval > time.Second {
+		interval = time.Second
+	}
+	// NewTicker panics on a zero interval, which timeouts under 4ns give
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if c.paused.Load() {
+				continue
+			}
+			idle := time.Since(time.Unix(0, c.lastResult.Load()))
+			if idle > c.Config.IdleTimeout {
+				c.halt("no new results for " + c.Config.IdleTimeout.String())
+				return
+			}
+		}
+	}
+}
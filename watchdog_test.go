@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchIdleTinyTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{1, 3, time.Microsecond} {
+		c, err := New(Config{IdleTimeout: timeout})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.lastResult.Store(time.Now().UnixNano())
+		done := make(chan struct{})
+		returned := make(chan struct{})
+		go func() {
+			c.watchIdle(done)
+			close(returned)
+		}()
+		select {
+		case <-returned:
+		case <-time.After(5 * time.Second):
+			close(done)
+			t.Fatalf("IdleTimeout %v: watchdog never fired", timeout)
+		}
+		if !c.stopped() {
+			t.Errorf("IdleTimeout %v: crawl not halted", timeout)
+		}
+	}
+}
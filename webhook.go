@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	webhookQueueSize = 1024
+	webhookAttempts  = 3
+	// webhookDrainTimeout bounds how long Close waits for queued results
+	webhookDrainTimeout = 30 * time.Second
+)
+
+type webhookPayload struct {
+	RunID  string `json:"run_id"`
+	Target string `json:"target"`
+	Result Result `json:"result"`
+}
+
+// webhookSink POSTs each result to WebhookURL from a background goroutine.
+// When the endpoint can't keep up, results that don't fit in the queue are
+// dropped rather than slowing the crawl down. Sending stops with the crawl's
+// context, the results still queued are then lost.
+type webhookSink struct {
+	c       *Crawler
+	client  *http.Client
+	queue   chan Result
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	dropped atomic.Int64
+	lost    atomic.Int64
+}
+
+func newWebhookSink(c *Crawler) *webhookSink {
+	ctx, cancel := context.WithCancel(c.context())
+	s := &webhookSink{
+		c:      c,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan Result, webhookQueueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	go func() {
+		defer close(s.done)
+		for r := range s.queue {
+			if ctx.Err() != nil {
+				s.lost.Add(1)
+				continue
+			}
+			err := s.post(r)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				s.lost.Add(1)
+			case err != nil && c.Config.Verbose:
+				c.printf("[%s] webhook %s: %v\n", color.RedString("ERR"), r.URL, err)
+			}
+		}
+	}()
+	return s
+}
+
+// Write hands r to the sender without blocking.
+func (s *webhookSink) Write(r Result) error {
+	select {
+	case s.queue <- r:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// Close waits for the queue to drain, for webhookDrainTimeout at most.
+func (s *webhookSink) Close() error {
+	close(s.queue)
+	select {
+	case <-s.done:
+	case <-time.After(webhookDrainTimeout):
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+	if dropped := s.dropped.Load(); dropped > 0 {
+		s.c.logf(color.YellowString, "[WRN] %d results not sent to the webhook (queue full)", dropped)
+	}
+	if lost := s.lost.Load(); lost > 0 {
+		s.c.logf(color.YellowString, "[WRN] %d results not sent to the webhook (interrupted or timed out)", lost)
+	}
+	return nil
+}
+
+// post sends one result, retrying with backoff on network errors, 429 and
+// 5xx responses.
+func (s *webhookSink) post(r Result) error {
+	body, err := json.Marshal(webhookPayload{RunID: s.c.RunID, Target: s.c.Config.TargetURL, Result: r})
+	if err != nil {
+		return err
+	}
+
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(s.ctx, "POST", s.c.Config.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("endpoint returned %s", resp.Status)
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return err
+			}
+		}
+		if attempt == webhookAttempts {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// addWebSocket records a ws:// or wss:// endpoint the first time it is seen.
+func (c *Crawler) addWebSocket(u, foundOn string) {
+	if _, loaded := c.Visited.LoadOrStore(u, true); loaded {
+		return
+	}
+	c.printf("[%s] %s\n", color.BlueString("WSS"), u)
+	c.resultsMu.Lock()
+	c.WebSockets = append(c.WebSockets, Result{
+		URL:          u,
+		FoundOn:      foundOn,
+		DiscoveredAt: time.Now(),
+	})
+	c.resultsMu.Unlock()
+}
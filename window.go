@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// TimeWindow is a daily time-of-day range in local time, as offsets from
+// midnight. A window whose End is before its Start spans midnight. The zero
+// value is always open.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseTimeWindow parses a "HH:MM-HH:MM" range such as "22:00-06:00".
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q, want HH:MM-HH:MM", s)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	sinceMidnight := func(t time.Time) time.Duration {
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return TimeWindow{Start: sinceMidnight(start), End: sinceMidnight(end)}, nil
+}
+
+// untilOpen returns how long until the window next opens, 0 if t is inside.
+func (w TimeWindow) untilOpen(t time.Time) time.Duration {
+	if w.Start == w.End {
+		return 0
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	now := t.Sub(midnight)
+
+	open := now >= w.Start && now < w.End
+	if w.End < w.Start {
+		open = now >= w.Start || now < w.End
+	}
+	if open {
+		return 0
+	}
+	if now < w.Start {
+		return w.Start - now
+	}
+	return 24*time.Hour - now + w.Start
+}
+
+// waitWindow blocks while the time of day is outside CrawlWindow, or until
+// the crawl is cancelled. The idle watchdog is held off for the duration of
+// the pause.
+func (c *Crawler) waitWindow() {
+	for !c.stopped() {
+		wait := c.Config.CrawlWindow.untilOpen(time.Now())
+		if wait == 0 {
+			if c.paused.CompareAndSwap(true, false) {
+				c.lastResult.Store(time.Now().UnixNano())
+				c.logf(color.GreenString, "[INF] Crawl window open, resuming")
+			}
+			return
+		}
+		if c.paused.CompareAndSwap(false, true) {
+			c.logf(color.YellowString, "[WRN] Outside crawl window, pausing until %s", time.Now().Add(wait).Format("15:04"))
+		}
+		select {
+		case <-time.After(min(wait, time.Minute)):
+		case <-c.context().Done():
+			return
+		}
+	}
+}